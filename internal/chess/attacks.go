@@ -0,0 +1,238 @@
+package chess
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// DATA DEFINITIONS
+
+// Precomputed attack sets for every square, indexed by bitboard shifts
+var knightAttacks [64]uint64
+var kingAttacks [64]uint64
+
+// Pawn attacks indexed by color index (0 white, 1 black) then square
+var pawnAttacks [2][64]uint64
+
+// A magic lookup for the attacks of a sliding piece from one square
+type magic struct {
+	mask    uint64   // relevant occupancy (board edges excluded)
+	number  uint64   // multiplier that maps each occupancy to a unique index
+	shift   uint     // 64 minus the number of bits in mask
+	attacks []uint64 // attack sets indexed by the magic index
+}
+
+var rookMagics [64]magic
+var bishopMagics [64]magic
+
+// The squares strictly between two squares on the same rank, file or
+// diagonal, empty if they are not aligned
+var betweenSquares [64][64]uint64
+
+var rookDirections = [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+var bishopDirections = [4][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// Magic multipliers for each square, found with a random search for sparse
+// numbers that map every relevant occupancy to a collision free index
+var rookMagicNumbers = [64]uint64{
+	0x018000e0c0008010, 0x0840200010004000, 0x9c80200109100080, 0x0a80040800801003,
+	0x2100100208010004, 0x0300080400022100, 0x210000c100020004, 0x0180090000a05080,
+	0x0120801080204006, 0x0082404000201000, 0x024a004200208010, 0x3404800803821000,
+	0x0400800800800400, 0x0001000900020400, 0x1000800200010080, 0x8002000080440122,
+	0x400024800040048c, 0x0000850040010022, 0x4000888010002000, 0x0200848010000800,
+	0x0010050011000800, 0x4204008002000480, 0x2020040002081001, 0x0804020000a40041,
+	0x00c0400080002080, 0x0021020a00208040, 0x0000488200120020, 0x8040080080801000,
+	0x0a00080080800400, 0x400a000200040810, 0x0a00020080800100, 0x0610028600224104,
+	0x4080004000402000, 0x8002004102002084, 0x0060a00081801000, 0x0468000880801004,
+	0x8110040801001100, 0x0410800200800400, 0x8002000482000108, 0x0241808042000401,
+	0xc000800040018020, 0x0880200040008080, 0x8220150220010040, 0x2881001000210009,
+	0x0000080011010004, 0x4842003005a20008, 0x0010900d26040028, 0x0005000280570022,
+	0x0012082100408200, 0x3000400081002100, 0x0600100080200080, 0x0000090024100100,
+	0x0000040080280180, 0x8284008004020080, 0x0000080201100400, 0x0400110840840200,
+	0x000201002040108a, 0x4000208c11004001, 0x0400090010402001, 0x0000082100041001,
+	0x00820005a0100802, 0x004900020c00080b, 0x0812000804010082, 0x011c049104004422,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x02021850440080a0, 0x40480808304050c0, 0x08104080a1000005, 0x22080a0020001201,
+	0x2804242000000002, 0x4b00882108000800, 0x3802080425041000, 0x1010220210010841,
+	0x8404080208080110, 0x0002302220842284, 0x0020902400535020, 0x0020022082010000,
+	0x0000240420008002, 0x0001120803080408, 0x0040208088201000, 0x00020a0101088201,
+	0x0040000802640410, 0x2008000410008221, 0x0004800808230200, 0x0000808802810023,
+	0x2004001080a04808, 0x0025020880600210, 0x0814004511082241, 0x2080442200520822,
+	0x0020904420030200, 0x01a2284811300084, 0x2800500001040282, 0x5cb4010020200880,
+	0xc001001021004001, 0x0c30010006808480, 0x0804031000809080, 0x0202a28022020090,
+	0x0210825010081004, 0x4004010400491000, 0x0030a21100080800, 0x0280040400280210,
+	0x4040010100241040, 0x3084240020141000, 0x4408021060a40109, 0x064c208210088240,
+	0x00b1082104009040, 0x8006080108001480, 0x0100140224001804, 0x0409004200802800,
+	0x0010080104005040, 0x0221010909000a00, 0x2284080204180042, 0x00044082114c0200,
+	0x0282008208c08004, 0x8001010801044208, 0x8820010401040084, 0x8400000084042c15,
+	0x0424421002022040, 0x4002880208020400, 0x6010208801004800, 0x6020490921050c05,
+	0x1002024124012001, 0x0000010441100944, 0x6000410500491024, 0x0100080008842400,
+	0x2092020005208200, 0x1482002064100880, 0x8003c2d2060a0200, 0x80244c1016220010,
+}
+
+func init() {
+	knightShifts := [8][2]int{{1, 2}, {-1, 2}, {1, -2}, {-1, -2}, {2, 1}, {-2, 1}, {2, -1}, {-2, -1}}
+	kingShifts := [8][2]int{{0, 1}, {1, 0}, {1, 1}, {0, -1}, {-1, 0}, {-1, -1}, {-1, 1}, {1, -1}}
+
+	for square := 0; square < 64; square++ {
+		pos := BitboardShiftsToPos(square)
+		knightAttacks[square] = calcShiftedBitboard(pos, knightShifts[:])
+		kingAttacks[square] = calcShiftedBitboard(pos, kingShifts[:])
+		pawnAttacks[0][square] = calcShiftedBitboard(pos, [][2]int{{1, 1}, {1, -1}})
+		pawnAttacks[1][square] = calcShiftedBitboard(pos, [][2]int{{-1, 1}, {-1, -1}})
+	}
+
+	for square := 0; square < 64; square++ {
+		rookMagics[square] = createMagic(square, rookDirections, rookMagicNumbers[square])
+		bishopMagics[square] = createMagic(square, bishopDirections, bishopMagicNumbers[square])
+	}
+
+	for from := 0; from < 64; from++ {
+		for to := 0; to < 64; to++ {
+			fromBit, toBit := uint64(1)<<from, uint64(1)<<to
+			if rookAttacks(from, 0)&toBit != 0 {
+				betweenSquares[from][to] = rookAttacks(from, toBit) & rookAttacks(to, fromBit)
+			}
+			if bishopAttacks(from, 0)&toBit != 0 {
+				betweenSquares[from][to] = bishopAttacks(from, toBit) & bishopAttacks(to, fromBit)
+			}
+		}
+	}
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Returns a bitboard of the pieces of either color that attack the position
+func (board *Board) AttackersOf(pos Pos) uint64 {
+	return board.attackersOf(PosToBitboardShifts(pos), board.Occupancy)
+}
+
+// Returns true if any piece of the given color attacks the position
+func (board *Board) IsSquareAttacked(pos Pos, byColor int) bool {
+	return board.AttackersOf(pos)&board.colorOccupancy(byColor) != 0
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Returns a bitboard of the pieces of either color that attack the square
+// with sliding attacks blocked by the given occupancy
+func (board *Board) attackersOf(square int, occupancy uint64) uint64 {
+	bitboards := &board.Bitboards
+	white := GetBitboardIndex(CreatePiece(Pawn | White))
+	black := GetBitboardIndex(CreatePiece(Pawn | Black))
+	pieces := func(pieceType int) uint64 {
+		return bitboards[white+pieceType-1] | bitboards[black+pieceType-1]
+	}
+
+	// a pawn attacks the square if a pawn of the other color on the square would attack it
+	attackers := pawnAttacks[colorIndex(Black)][square] & bitboards[white]
+	attackers |= pawnAttacks[colorIndex(White)][square] & bitboards[black]
+	attackers |= knightAttacks[square] & pieces(Knight)
+	attackers |= kingAttacks[square] & pieces(King)
+	attackers |= bishopAttacks(square, occupancy) & (pieces(Bishop) | pieces(Queen))
+	attackers |= rookAttacks(square, occupancy) & (pieces(Rook) | pieces(Queen))
+	return attackers
+}
+
+// Returns true if the king of the given color is attacked by the other color
+func (board *Board) isKingAttacked(color int) bool {
+	king := board.Bitboards[GetBitboardIndex(CreatePiece(King|color))]
+	return board.IsSquareAttacked(CalcPosFromBitboard(king), oppositeColor(color))
+}
+
+// Returns the squares attacked by a rook on the square given the occupancy
+func rookAttacks(square int, occupancy uint64) uint64 {
+	m := &rookMagics[square]
+	return m.attacks[((occupancy&m.mask)*m.number)>>m.shift]
+}
+
+// Returns the squares attacked by a bishop on the square given the occupancy
+func bishopAttacks(square int, occupancy uint64) uint64 {
+	m := &bishopMagics[square]
+	return m.attacks[((occupancy&m.mask)*m.number)>>m.shift]
+}
+
+// Returns the squares attacked by a queen on the square given the occupancy
+func queenAttacks(square int, occupancy uint64) uint64 {
+	return rookAttacks(square, occupancy) | bishopAttacks(square, occupancy)
+}
+
+// Returns the index (0 white, 1 black) used by tables split by color
+func colorIndex(color int) int {
+	return color >> 3
+}
+
+// Returns a bitboard of every legal position shifted from pos
+func calcShiftedBitboard(pos Pos, shifts [][2]int) uint64 {
+	bitboard := uint64(0)
+	for _, shift := range shifts {
+		if !IsShiftIllegal(pos, shift[0], shift[1]) {
+			bitboard |= CalcBitboard(ShiftPos(pos, shift[0], shift[1]))
+		}
+	}
+	return bitboard
+}
+
+// Returns the sliding attacks from the square by walking each direction
+// until a piece in the occupancy or the board edge is reached
+func calcSlidingAttacks(square int, occupancy uint64, directions [4][2]int) uint64 {
+	attacks := uint64(0)
+	start := BitboardShiftsToPos(square)
+	for _, direction := range directions {
+		pos := start
+		for !IsShiftIllegal(pos, direction[0], direction[1]) {
+			pos = ShiftPos(pos, direction[0], direction[1])
+			bitboard := CalcBitboard(pos)
+			attacks |= bitboard
+			if occupancy&bitboard != 0 {
+				break
+			}
+		}
+	}
+	return attacks
+}
+
+// Returns the squares whose occupancy can block a slider on the square
+func calcRelevantMask(square int, directions [4][2]int) uint64 {
+	mask := uint64(0)
+	start := BitboardShiftsToPos(square)
+	for _, direction := range directions {
+		pos := start
+		// the last square of a ray never blocks anything behind it
+		for !IsShiftIllegal(pos, 2*direction[0], 2*direction[1]) {
+			pos = ShiftPos(pos, direction[0], direction[1])
+			mask |= CalcBitboard(pos)
+		}
+	}
+	return mask
+}
+
+// Creates the magic lookup for the square by filling its attack table
+func createMagic(square int, directions [4][2]int, number uint64) magic {
+	mask := calcRelevantMask(square, directions)
+	relevantBits := bits.OnesCount64(mask)
+	m := magic{
+		mask:    mask,
+		number:  number,
+		shift:   uint(64 - relevantBits),
+		attacks: make([]uint64, 1<<relevantBits),
+	}
+
+	// enumerate every subset of the mask with the carry rippler trick
+	subset := uint64(0)
+	for {
+		attacks := calcSlidingAttacks(square, subset, directions)
+		index := (subset * m.number) >> m.shift
+		if m.attacks[index] != 0 && m.attacks[index] != attacks {
+			panic(fmt.Sprintf("Magic number %#x collides on square %d", number, square))
+		}
+		m.attacks[index] = attacks
+		subset = (subset - mask) & mask
+		if subset == 0 {
+			break
+		}
+	}
+	return m
+}
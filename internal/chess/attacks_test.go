@@ -0,0 +1,45 @@
+package chess
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestMagicAttacks compares the magic lookups with attacks calculated by
+// walking each ray for random occupancies on every square.
+func TestMagicAttacks(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for square := 0; square < 64; square++ {
+		for i := 0; i < 100; i++ {
+			occupancy := random.Uint64() & random.Uint64()
+
+			expected := calcSlidingAttacks(square, occupancy, rookDirections)
+			if result := rookAttacks(square, occupancy); result != expected {
+				t.Errorf(`rookAttacks(%d, %#x) = %#x want match for %#x`, square, occupancy, result, expected)
+			}
+
+			expected = calcSlidingAttacks(square, occupancy, bishopDirections)
+			if result := bishopAttacks(square, occupancy); result != expected {
+				t.Errorf(`bishopAttacks(%d, %#x) = %#x want match for %#x`, square, occupancy, result, expected)
+			}
+		}
+	}
+}
+
+// TestAttackersOf checks the attackers of a square in the starting position.
+func TestAttackersOf(t *testing.T) {
+	// Parameters
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	pos := LoadPos("f3")
+	expected := CalcBitboard(LoadPos("e2")) | CalcBitboard(LoadPos("g2")) | CalcBitboard(LoadPos("g1"))
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	attackers := board.AttackersOf(pos)
+	if attackers != expected {
+		t.Errorf(`AttackersOf("%s") = %#x want match for %#x`, PosToAlgebraic(pos), attackers, expected)
+	}
+	if board.IsSquareAttacked(pos, Black) {
+		t.Errorf(`IsSquareAttacked("%s", Black) = true want match for false`, PosToAlgebraic(pos))
+	}
+}
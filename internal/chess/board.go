@@ -2,7 +2,8 @@ package chess
 
 import (
 	"fmt"
-	"math"
+	"math/bits"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -35,10 +36,10 @@ const (
 	King   int = 6
 )
 
+// Squares of each color, a1 is a dark square
 const (
-	GamePlayState = 0
-	GameTiedState = 1
-	GameWonState  = 2
+	DarkSquares  uint64 = 0xAA55AA55AA55AA55
+	LightSquares uint64 = ^DarkSquares
 )
 
 // fourth bit (what color is this piece)
@@ -55,13 +56,28 @@ type Pos struct {
 
 // Stores a board state (equivalent to FEN data)
 type Board struct {
-	Bitboards   [12]uint64
-	PieceLists  [12][]Piece // to implement
-	ActiveColor int
-	Castling    string
-	EnPassant   *Pos
-	HalfMoves   int
-	FullMoves   int
+	Bitboards         [12]uint64
+	ColorOccupancy    [2]uint64
+	Occupancy         uint64
+	Mailbox           [64]Piece
+	ActiveColor       int
+	Castling          string
+	CastlingRookFiles [4]int
+	Chess960          bool
+	EnPassant         *Pos
+	HalfMoves         int
+	FullMoves         int
+	hash              uint64
+	history           []uint64
+}
+
+// Stores the board state that a move destroys so that it can be unmade
+type Undo struct {
+	Captured  Piece
+	Castling  string
+	EnPassant *Pos
+	HalfMoves int
+	Hash      uint64
 }
 
 /*
@@ -70,6 +86,15 @@ Board
 - Bitboards: [12]uint64
 A list of breadboards for each piece type/color combination
 
+- ColorOccupancy: [2]uint64
+The squares occupied by white pieces (index 0) and black pieces (index 1).
+
+- Occupancy: uint64
+The squares occupied by pieces of either color.
+
+- Mailbox: [64]Piece
+The piece on each square, indexed by bitboard shifts. None if the square is
+empty. Kept in sync with the bitboards by Add and Remove.
 
 - ActiveColor: int
 Either 0 or 8 depending on which color is to move next on the board.
@@ -79,6 +104,15 @@ Empty string if no castling is possible for either color. Contains upper case
 letters K, Q for kingside and queenside castling with white. Lower case
 for black.
 
+- CastlingRookFiles: [4]int
+The file of the rook each castling right castles with, in KQkq order. Always
+the h and a files in standard chess, set from the FEN in Chess960.
+
+- Chess960: bool
+True if the game is played with Chess960 rules, which only changes how
+castling moves and rights are written (see MoveToUCI and FEN). The moves
+themselves follow the castling rook files in either variant.
+
 - EnPassant: Pos
 The square over which a pawn has just passed while moving two squares that
 might be vulnerable to attack by en passant. Empty position else.
@@ -91,6 +125,25 @@ fifty move rule.
 The number of the full moves. It starts at 1 and is incremented after
 Black's move.
 
+- hash: uint64
+The Zobrist hash of the position. Pieces are hashed by Add and Remove, the
+rest is updated by PlayMove. Read with Hash.
+
+- history: []uint64
+The hashes of the positions before each move played with PlayMove, oldest
+first. Only the last HalfMoves entries (those since the last irreversible
+move) can repeat the current position.
+
+
+Undo
+
+- Captured: Piece
+The piece captured by the move (including en passant captures). None if the
+move was not a capture.
+
+- Castling, EnPassant, HalfMoves, Hash
+The values of the board fields before the move was played.
+
 */
 
 // PUBLIC FUNCTION DEFINITIONS
@@ -99,43 +152,74 @@ Black's move.
 
 // Replaces the position on the board with given piece
 func (board *Board) Add(pos Pos, piece Piece) {
-	if board.Get(pos) != None {
+	square := PosToBitboardShifts(pos)
+	if board.Mailbox[square] != None {
 		board.Remove(pos)
 	}
 
-	board.Bitboards[GetBitboardIndex(piece)] |= CalcBitboard(pos)
+	bitboard := CalcBitboard(pos)
+	board.Bitboards[GetBitboardIndex(piece)] |= bitboard
+	board.ColorOccupancy[colorIndex(piece.Color())] |= bitboard
+	board.Occupancy |= bitboard
+	board.Mailbox[square] = piece
+	board.hash ^= zobristPieces[GetBitboardIndex(piece)][square]
 }
 
 // Gets the piece from the board at row, col
 func (board *Board) Get(pos Pos) Piece {
-	bitboard := CalcBitboard(pos)
-	for index := 0; index < 12; index++ {
-		if board.Bitboards[index]&bitboard != 0 {
-			return GetPieceFromIndex(index)
-		}
-	}
-	return None
+	return board.Mailbox[PosToBitboardShifts(pos)]
 }
 
 // Removes the piece from the board at row, col
 func (board *Board) Remove(pos Pos) Piece {
-	bitboard := CalcBitboard(pos)
-	for index := 0; index < 12; index++ {
-		if board.Bitboards[index]&bitboard != 0 {
-			piece := GetPieceFromIndex(index)
-			board.Bitboards[index] &= ^bitboard
-			return piece
-		}
+	square := PosToBitboardShifts(pos)
+	piece := board.Mailbox[square]
+	if piece == None {
+		return None
 	}
-	return None
+
+	bitboard := CalcBitboard(pos)
+	board.Bitboards[GetBitboardIndex(piece)] &^= bitboard
+	board.ColorOccupancy[colorIndex(piece.Color())] &^= bitboard
+	board.Occupancy &^= bitboard
+	board.Mailbox[square] = None
+	board.hash ^= zobristPieces[GetBitboardIndex(piece)][square]
+	return piece
 }
 
 // Play the given move on the board
-func (board *Board) PlayMove(move Move) {
+// Returns the undo record needed to take the move back with UnmakeMove
+func (board *Board) PlayMove(move Move) Undo {
+	undo := Undo{
+		Castling:  board.Castling,
+		EnPassant: board.EnPassant,
+		HalfMoves: board.HalfMoves,
+		Hash:      board.hash,
+	}
+
+	// boards copied by value share the history's backing array, so it is
+	// clipped to make the append copy it instead of writing over the other's
+	board.history = append(slices.Clip(board.history), board.hash)
+
+	// castling and en passant keys are replaced once the move is played
+	board.hash ^= board.castlingHash() ^ board.enPassantHash()
+
 	captureOrPawn := false
 
+	// rights are lost by moving the king or rook, or capturing the rook
+	lost := board.castlingRightsLost(move.Start, move.End)
+
+	// in Chess960 the king may land on the rook, so both are lifted first
 	piece := board.Remove(move.Start)
+	var rook Piece
+	var rookEnd Pos
+	if move.IsCastle() {
+		var rookStart Pos
+		rookStart, rookEnd = board.castlingRookMove(move)
+		rook = board.Remove(rookStart)
+	}
 	endPiece := board.Get(move.End)
+	undo.Captured = endPiece
 
 	if piece.Type() == Pawn || endPiece != None {
 		captureOrPawn = true
@@ -154,22 +238,9 @@ func (board *Board) PlayMove(move Move) {
 
 	board.Add(move.End, piece)
 
-	switch move.Flag {
-	case BreaksCastlingRightsFlag:
-		if piece.Type() == Rook {
-
-			leftPos := CreatePos(backRank, 1)
-			rightPos := CreatePos(backRank, 8)
+	board.removeCastlingRights(lost)
 
-			if move.Start == leftPos {
-				board.removeQueensideCastlingRights()
-			} else if move.Start == rightPos {
-				board.removeKingsideCastlingRights()
-			}
-		} else {
-			board.removeAllCastlingRights()
-		}
-		board.Add(move.End, piece)
+	switch move.Kind() {
 	case PromoteToKnightFlag:
 		board.Add(move.End, CreatePiece(Knight|board.ActiveColor))
 	case PromoteToBishopFlag:
@@ -178,26 +249,21 @@ func (board *Board) PlayMove(move Move) {
 		board.Add(move.End, CreatePiece(Rook|board.ActiveColor))
 	case PromoteToQueenFlag:
 		board.Add(move.End, CreatePiece(Queen|board.ActiveColor))
-	case CastleKingsideFlag:
-		rook := board.Remove(CreatePos(backRank, 8))
-		board.Add(ShiftPos(move.Start, 0, 1), rook)
-		board.removeAllCastlingRights()
-	case CastleQueensideFlag:
-		rook := board.Remove(CreatePos(backRank, 1))
-		board.Add(ShiftPos(move.Start, 0, -1), rook)
-		board.removeAllCastlingRights()
+	case CastleKingsideFlag, CastleQueensideFlag:
+		board.Add(rookEnd, rook)
 	case PawnDoublePushFlag:
 		pos := CreatePos(backRank+2*direction, move.Start.File)
 		board.EnPassant = &pos
 	case EnPassantFlag:
-		board.Remove(ShiftPos(move.End, -direction, 0)) // remove pawn
+		undo.Captured = board.Remove(ShiftPos(move.End, -direction, 0)) // remove pawn
 	}
 
-	if move.Flag != PawnDoublePushFlag {
+	if move.Kind() != PawnDoublePushFlag {
 		board.EnPassant = nil
 	}
 
 	board.changeActiveColor()
+	board.hash ^= zobristBlackToMove ^ board.castlingHash() ^ board.enPassantHash()
 
 	if board.ActiveColor == White {
 		board.FullMoves += 1
@@ -207,20 +273,78 @@ func (board *Board) PlayMove(move Move) {
 	} else {
 		board.HalfMoves = 0
 	}
+
+	return undo
+}
+
+// Takes back the given move using the undo record returned when it was played
+// The move must be the last move played on the board
+func (board *Board) UnmakeMove(move Move, undo Undo) {
+	board.changeActiveColor()
+
+	if board.ActiveColor == Black {
+		board.FullMoves -= 1
+	}
+
+	direction := 1
+	if board.ActiveColor == Black {
+		direction = -1
+	}
+
+	piece := board.Remove(move.End)
+
+	switch move.Kind() {
+	case PromoteToKnightFlag, PromoteToBishopFlag, PromoteToRookFlag, PromoteToQueenFlag:
+		piece = CreatePiece(Pawn | board.ActiveColor)
+	case CastleKingsideFlag, CastleQueensideFlag:
+		// the rights are restored first as they give the rook's start
+		board.Castling = undo.Castling
+		rookStart, rookEnd := board.castlingRookMove(move)
+		rook := board.Remove(rookEnd)
+		board.Add(rookStart, rook)
+	}
+
+	board.Add(move.Start, piece)
+
+	if undo.Captured != None {
+		capturePos := move.End
+		if move.Kind() == EnPassantFlag {
+			capturePos = ShiftPos(move.End, -direction, 0)
+		}
+		board.Add(capturePos, undo.Captured)
+	}
+
+	board.Castling = undo.Castling
+	board.EnPassant = undo.EnPassant
+	board.HalfMoves = undo.HalfMoves
+	board.hash = undo.Hash
+	board.history = board.history[:len(board.history)-1]
 }
 
 func (board *Board) Copy() Board {
 	newBoard := Board{
-		Bitboards:   board.Bitboards,
-		ActiveColor: board.ActiveColor,
-		Castling:    board.Castling,
-		EnPassant:   board.EnPassant, // TODO: FIX FUTURE BUG
-		HalfMoves:   board.HalfMoves,
-		FullMoves:   board.FullMoves,
+		Bitboards:         board.Bitboards,
+		ColorOccupancy:    board.ColorOccupancy,
+		Occupancy:         board.Occupancy,
+		Mailbox:           board.Mailbox,
+		ActiveColor:       board.ActiveColor,
+		Castling:          board.Castling,
+		CastlingRookFiles: board.CastlingRookFiles,
+		Chess960:          board.Chess960,
+		EnPassant:         board.EnPassant, // TODO: FIX FUTURE BUG
+		HalfMoves:         board.HalfMoves,
+		FullMoves:         board.FullMoves,
+		hash:              board.hash,
+		history:           slices.Clone(board.history),
 	}
 	return newBoard
 }
 
+// Returns a bitboard of every square occupied by a piece of the given color
+func (board *Board) colorOccupancy(color int) uint64 {
+	return board.ColorOccupancy[colorIndex(color)]
+}
+
 // Flips the current active color
 func (board *Board) changeActiveColor() {
 	if board.ActiveColor == White {
@@ -230,45 +354,57 @@ func (board *Board) changeActiveColor() {
 	}
 }
 
-func (board *Board) removeAllCastlingRights() {
-	board.removeKingsideCastlingRights()
-	board.removeQueensideCastlingRights()
-}
-
-func (board *Board) removeKingsideCastlingRights() {
-	if board.ActiveColor == White {
-		board.Castling = strings.ReplaceAll(board.Castling, "K", "")
-	} else {
-		board.Castling = strings.ReplaceAll(board.Castling, "k", "")
+// Removes each of the given castling rights from the board
+func (board *Board) removeCastlingRights(rights string) {
+	for _, right := range rights {
+		board.Castling = strings.ReplaceAll(board.Castling, string(right), "")
 	}
 }
 
-func (board *Board) removeQueensideCastlingRights() {
-	if board.ActiveColor == White {
-		board.Castling = strings.ReplaceAll(board.Castling, "Q", "")
-	} else {
-		board.Castling = strings.ReplaceAll(board.Castling, "q", "")
-	}
+// Returns true if fifty moves by each player have passed without a capture
+// or pawn move
+func (board *Board) IsFiftyMoveRule() bool {
+	return board.HalfMoves >= 100
 }
 
-// Returns the current game state of either play, won, or tied
-func (board *Board) GetGameState() int {
-	noMoves := len(GetAllLegalMoves(*board)) == 0
-	inCheck := IsKingInCheck(*board)
+// Returns true if the current position has occurred at least three times
+func (board *Board) IsThreefoldRepetition() bool {
+	return board.countRepetitions() >= 2
+}
 
-	if noMoves && inCheck {
-		return GameWonState
+// Returns true if neither color has the material to ever checkmate
+// (lone kings, a single minor piece, or only bishops all on one square color)
+func (board *Board) IsInsufficientMaterial() bool {
+	pieces := func(pieceType int) uint64 {
+		return board.Bitboards[GetBitboardIndex(CreatePiece(pieceType|White))] |
+			board.Bitboards[GetBitboardIndex(CreatePiece(pieceType|Black))]
 	}
 
-	if noMoves {
-		return GameTiedState
+	if pieces(Pawn)|pieces(Rook)|pieces(Queen) != 0 {
+		return false
 	}
 
-	// fifity move rule ignored
+	knights := pieces(Knight)
+	bishops := pieces(Bishop)
+	if bits.OnesCount64(knights|bishops) <= 1 {
+		return true
+	}
 
-	// 3 fold repetition
+	// bishops on one square color can never cover the king's escape squares
+	return knights == 0 && (bishops&LightSquares == 0 || bishops&DarkSquares == 0)
+}
 
-	return GamePlayState
+// Returns the number of earlier occurrences of the current position
+func (board *Board) countRepetitions() int {
+	count := 0
+	limit := min(board.HalfMoves, len(board.history))
+	// the same color must be to move so only every other position can match
+	for back := 2; back <= limit; back += 2 {
+		if board.history[len(board.history)-back] == board.hash {
+			count++
+		}
+	}
+	return count
 }
 
 // PIECE FUNCTIONS
@@ -336,42 +472,30 @@ func CreatePos(rank int, file int) Pos {
 
 // calcBitboardPos returns a bitboard containing the given pos
 func CalcBitboard(pos Pos) uint64 {
-	answer := uint64(1) << (8*(pos.Rank-1) + pos.File - 1)
-
-	callPanic := func() {
-		fmt.Println(pos.Rank)
-		fmt.Println(pos.File)
-		panic(fmt.Sprintf("The calculated bitbord position: %d, is invalid", answer))
-	}
-
-	if answer > uint64(math.Ceil(math.Pow(2, 64)-1)-(math.Pow(2, 63)-1)) {
-		callPanic()
+	if pos.Rank < 1 || pos.Rank > 8 || pos.File < 1 || pos.File > 8 {
+		panic(fmt.Sprintf("The bitboard position of rank: %d, file: %d is invalid", pos.Rank, pos.File))
 	}
-	return uint64(answer)
+	return uint64(1) << PosToBitboardShifts(pos)
 }
 
 // Returns the position created from the given bitboard with a single piece
 // Requires the given bitboard to contain exactly a single "1" binary digit
 func CalcPosFromBitboard(bitboard uint64) Pos {
-	// TODO: optimize
-	shifts := 0
-	shiftBoard := bitboard
-	for shiftBoard > 0 {
-		shiftBoard = shiftBoard >> 1
-		shifts += 1
-	}
-	shifts -= 1
-
-	return BitboardShiftsToPos(shifts)
+	return BitboardShiftsToPos(bits.TrailingZeros64(bitboard))
 }
 
 // Returns the position created from the number of trailing zeros on the bitboard
 func BitboardShiftsToPos(shifts int) Pos {
-	rank := int(math.Floor(float64(shifts)/8)) + 1
+	rank := shifts/8 + 1
 	file := (shifts % 8) + 1
 	return CreatePos(rank, file)
 }
 
+// Returns the number of trailing zeros on the bitboard containing the position
+func PosToBitboardShifts(pos Pos) int {
+	return 8*(pos.Rank-1) + pos.File - 1
+}
+
 // Returns the index of the bitboard containing board information for that piece
 func GetBitboardIndex(piece Piece) int {
 	colorIndex := 0
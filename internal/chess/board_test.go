@@ -0,0 +1,219 @@
+package chess
+
+import "testing"
+
+// TestUnmakeMoveRestoresBoard plays and unmakes every move in a perft tree,
+// checking that the board is restored to its previous state each time.
+func TestUnmakeMoveRestoresBoard(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	depth := 3
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	checkUnmakeMove(t, &board, depth)
+}
+
+func checkUnmakeMove(t *testing.T, board *Board, depth int) {
+	if depth == 0 {
+		return
+	}
+	for _, move := range GetAllLegalMoves(*board) {
+		before := board.Copy()
+		undo := board.PlayMove(move)
+		checkUnmakeMove(t, board, depth-1)
+		board.UnmakeMove(move, undo)
+		if !sameBoard(before, *board) {
+			t.Fatalf(`UnmakeMove(%s) did not restore the board`, MoveToAlgebraic(move))
+		}
+	}
+}
+
+// Returns true if the two boards store the same state
+func sameBoard(a Board, b Board) bool {
+	if a.Bitboards != b.Bitboards || a.Mailbox != b.Mailbox ||
+		a.ColorOccupancy != b.ColorOccupancy || a.Occupancy != b.Occupancy ||
+		a.ActiveColor != b.ActiveColor ||
+		a.Castling != b.Castling || a.HalfMoves != b.HalfMoves ||
+		a.FullMoves != b.FullMoves {
+		return false
+	}
+	if a.EnPassant == nil || b.EnPassant == nil {
+		return a.EnPassant == b.EnPassant
+	}
+	return *a.EnPassant == *b.EnPassant
+}
+
+// TestOccupancyInSync checks that the mailbox and occupancy bitboards agree
+// with the piece bitboards after loading a position.
+func TestOccupancyInSync(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	var occupancy [2]uint64
+	for index, bitboard := range board.Bitboards {
+		piece := GetPieceFromIndex(index)
+		occupancy[colorIndex(piece.Color())] |= bitboard
+		for square := 0; square < 64; square++ {
+			if bitboard&(uint64(1)<<square) != 0 && board.Mailbox[square] != piece {
+				t.Errorf(`Mailbox[%d] = %d want match for %d`, square, board.Mailbox[square], piece)
+			}
+		}
+	}
+	if occupancy != board.ColorOccupancy || occupancy[0]|occupancy[1] != board.Occupancy {
+		t.Errorf(`LoadBoardFromFEN("%s") occupancy does not match the piece bitboards`, fen)
+	}
+}
+
+// TestThreefoldRepetition shuffles the knights back to the starting position
+// twice, checking that the game is drawn only on the third occurrence.
+func TestThreefoldRepetition(t *testing.T) {
+	// Parameters
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	shuffle := []Move{
+		{Start: LoadPos("g1"), End: LoadPos("f3"), Flag: NoFlag},
+		{Start: LoadPos("g8"), End: LoadPos("f6"), Flag: NoFlag},
+		{Start: LoadPos("f3"), End: LoadPos("g1"), Flag: NoFlag},
+		{Start: LoadPos("f6"), End: LoadPos("g8"), Flag: NoFlag},
+	}
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	for repeat := 0; repeat < 2; repeat++ {
+		for _, move := range shuffle {
+			if result := board.GetGameResult(); result.IsOver() {
+				t.Fatalf(`GetGameResult() = %s by %s want match for ongoing`, result.Outcome, result.Termination)
+			}
+			board.PlayMove(move)
+		}
+	}
+	expected := GameResult{Outcome: Draw, Termination: Repetition}
+	if result := board.GetGameResult(); result != expected {
+		t.Errorf(`GetGameResult() = %s by %s want match for %s by %s`, result.Outcome, result.Termination, expected.Outcome, expected.Termination)
+	}
+}
+
+// TestRepetitionAfterCopy plays different moves on two value copies of a
+// board in turn, checking that each counts the same repetitions as a board
+// that played its moves alone.
+func TestRepetitionAfterCopy(t *testing.T) {
+	// Parameters
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	shared := []string{"g1f3", "g8f6", "f3g1", "f6g8", "b1c3", "b8c6", "c3b1", "c6b8", "g1f3"}
+	kingside := []string{"g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1", "f6g8", "g1f3"}
+	queenside := []string{"b8c6", "b1c3", "c6b8", "c3b1", "b8c6", "b1c3", "c6b8", "c3b1"}
+
+	// Test
+	a := LoadBoardFromFEN(fen)
+	playUCIMoves(t, &a, shared)
+	b := a
+	for index := range kingside {
+		playUCIMoves(t, &a, kingside[index:index+1])
+		playUCIMoves(t, &b, queenside[index:index+1])
+
+		for _, check := range []struct {
+			board *Board
+			moves []string
+		}{{&a, kingside[:index+1]}, {&b, queenside[:index+1]}} {
+			alone := LoadBoardFromFEN(fen)
+			playUCIMoves(t, &alone, shared)
+			playUCIMoves(t, &alone, check.moves)
+			if count, want := check.board.countRepetitions(), alone.countRepetitions(); count != want {
+				t.Fatalf(`countRepetitions("%s") = %d want match for %d`, check.board.FEN(), count, want)
+			}
+		}
+	}
+	if !a.IsThreefoldRepetition() || !b.IsThreefoldRepetition() {
+		t.Errorf(`IsThreefoldRepetition() = %t, %t want match for true, true`, a.IsThreefoldRepetition(), b.IsThreefoldRepetition())
+	}
+}
+
+// Plays the moves given in UCI notation on the board
+func playUCIMoves(t *testing.T, board *Board, moves []string) {
+	for _, uci := range moves {
+		move, err := ParseUCIMove(*board, uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		board.PlayMove(move)
+	}
+}
+
+// TestFiftyMoveRule plays a quiet move with 99 half moves on the clock,
+// checking that the game is then drawn.
+func TestFiftyMoveRule(t *testing.T) {
+	// Parameters
+	fen := "4k3/8/8/8/8/8/4P3/4K2R w - - 99 80"
+	move := Move{Start: LoadPos("h1"), End: LoadPos("h2"), Flag: BreaksCastlingRightsFlag}
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	board.PlayMove(move)
+	expected := GameResult{Outcome: Draw, Termination: FiftyMoveRule}
+	if result := board.GetGameResult(); result != expected {
+		t.Errorf(`GetGameResult() = %s by %s want match for %s by %s`, result.Outcome, result.Termination, expected.Outcome, expected.Termination)
+	}
+}
+
+// TestStalemate checks that a side with no legal moves and not in check
+// is drawn by stalemate.
+func TestStalemate(t *testing.T) {
+	// Parameters
+	fen := "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1"
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	expected := GameResult{Outcome: Draw, Termination: Stalemate}
+	if result := board.GetGameResult(); result != expected {
+		t.Errorf(`GetGameResult("%s") = %s by %s want match for %s by %s`, fen, result.Outcome, result.Termination, expected.Outcome, expected.Termination)
+	}
+}
+
+// TestInsufficientMaterial checks positions that can and cannot be won.
+func TestInsufficientMaterial(t *testing.T) {
+	// Parameters
+	expected := map[string]bool{
+		"8/8/4k3/8/8/3K4/8/8 w - - 0 1":    true,  // K vs K
+		"8/8/4k3/8/8/3KB3/8/8 w - - 0 1":   true,  // K+B vs K
+		"8/8/4k3/8/8/3KN3/8/8 b - - 0 1":   true,  // K+N vs K
+		"8/8/3bk3/8/8/3KB3/8/8 w - - 0 1":  true,  // bishops on dark squares
+		"8/8/2b1k3/8/8/3KB3/8/8 w - - 0 1": false, // opposite colored bishops
+		"8/8/4k3/8/8/3KNN2/8/8 w - - 0 1":  false, // two knights
+		"8/8/4k3/8/8/3K4/4P3/8 w - - 0 1":  false, // pawn
+		"8/8/4k3/8/8/3K4/8/7R w - - 0 1":   false, // rook
+		"8/8/4kn2/8/8/3KB3/8/8 w - - 0 1":  false, // bishop and knight
+	}
+
+	// Test
+	for fen, want := range expected {
+		board := LoadBoardFromFEN(fen)
+		if result := board.IsInsufficientMaterial(); result != want {
+			t.Errorf(`IsInsufficientMaterial("%s") = %t want match for %t`, fen, result, want)
+		}
+	}
+}
+
+// TestCaptureRemovesCastlingRights captures an unmoved rook, checking that
+// the defender loses the castling right on that side.
+func TestCaptureRemovesCastlingRights(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+	uci := "h1h8"
+	expected := "Qq"
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	move, err := ParseUCIMove(board, uci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !move.IsCapture() || !move.BreaksCastlingRights() {
+		t.Errorf(`ParseUCIMove("%s", %s).Flag = %d want match for %d`, fen, uci, move.Flag, CaptureFlag|BreaksCastlingRightsFlag)
+	}
+	board.PlayMove(move)
+	if board.Castling != expected {
+		t.Errorf(`PlayMove(%s).Castling = %s want match for %s`, uci, board.Castling, expected)
+	}
+}
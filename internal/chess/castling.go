@@ -0,0 +1,84 @@
+package chess
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// DATA DEFINITIONS
+
+// The castling rights in the order of Board.CastlingRookFiles
+const castlingRights = "KQkq"
+
+// The files the king and rook end on for each kind of castle, the same in
+// standard chess and Chess960
+var castlingEndFiles = map[int][2]int{
+	CastleKingsideFlag:  {7, 6},
+	CastleQueensideFlag: {3, 4},
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Returns the castling right of the color for the kind of castle
+func castlingRight(color int, kind int) rune {
+	right := 'K'
+	if kind == CastleQueensideFlag {
+		right = 'Q'
+	}
+	if color == Black {
+		right += 'a' - 'A'
+	}
+	return right
+}
+
+// Returns the color of the castling right
+func castlingRightColor(right rune) int {
+	if right >= 'a' {
+		return Black
+	}
+	return White
+}
+
+// Returns the position of the rook the castling right castles with
+func (board *Board) castlingRookPos(right rune) Pos {
+	backRank := 1
+	if castlingRightColor(right) == Black {
+		backRank = 8
+	}
+	return CreatePos(backRank, board.CastlingRookFiles[strings.IndexRune(castlingRights, right)])
+}
+
+// Returns the start and end positions of the rook in a castle by the active
+// color, which must still have the castling right
+func (board *Board) castlingRookMove(move Move) (Pos, Pos) {
+	start := board.castlingRookPos(castlingRight(board.ActiveColor, move.Kind()))
+	return start, CreatePos(start.Rank, castlingEndFiles[move.Kind()][1])
+}
+
+// Returns the castling rights lost by a move from start to end: both rights
+// of a color when its king moves and one right when its rook moves or is
+// captured
+func (board *Board) castlingRightsLost(start Pos, end Pos) string {
+	lost := ""
+	for _, right := range board.Castling {
+		king := board.Bitboards[GetBitboardIndex(CreatePiece(King|castlingRightColor(right)))]
+		rook := board.castlingRookPos(right)
+		if PosToBitboardShifts(start) == bits.TrailingZeros64(king) || start == rook || end == rook {
+			lost += string(right)
+		}
+	}
+	return lost
+}
+
+// Returns a bitboard of the positions on the rank from one file to another,
+// both included
+func calcRankSpan(rank int, from int, to int) uint64 {
+	if from > to {
+		from, to = to, from
+	}
+	var span uint64
+	for file := from; file <= to; file++ {
+		span |= CalcBitboard(CreatePos(rank, file))
+	}
+	return span
+}
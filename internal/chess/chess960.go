@@ -0,0 +1,74 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DATA DEFINITIONS
+
+// The number of Chess960 starting positions
+const Chess960Positions = 960
+
+// The squares the two knights take among the five left after the bishops
+// and queen are placed, for each of the ten knight placements
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Returns the FEN of the Chess960 starting position with the given number
+// from 0 to 959, numbered as by Scharnagl so that 518 is the standard
+// starting position
+func Chess960FEN(id int) (string, error) {
+	if id < 0 || id >= Chess960Positions {
+		return "", fmt.Errorf("Cannot create Chess960 position %d: expected 0 to %d", id, Chess960Positions-1)
+	}
+
+	var pieces [8]rune
+	pieces[id%4*2+1] = 'b' // light squared bishop
+	id /= 4
+	pieces[id%4*2] = 'b' // dark squared bishop
+	id /= 4
+
+	// the queen, knights, then rook, king and rook fill the empty files in order
+	place := func(index int, piece rune) {
+		for file := range pieces {
+			if pieces[file] != 0 {
+				continue
+			}
+			if index == 0 {
+				pieces[file] = piece
+				return
+			}
+			index--
+		}
+	}
+	place(id%6, 'q')
+	id /= 6
+	knights := chess960Knights[id]
+	place(knights[1], 'n')
+	place(knights[0], 'n')
+	place(0, 'r')
+	place(0, 'k')
+	place(0, 'r')
+
+	backRank := string(pieces[:])
+	return backRank + "/pppppppp/8/8/8/8/PPPPPPPP/" + strings.ToUpper(backRank) + " w KQkq - 0 1", nil
+}
+
+// Returns a board set up in the Chess960 starting position with the given
+// number (see Chess960FEN), playing with Chess960 rules
+func ParseChess960(id int) (Board, error) {
+	fen, err := Chess960FEN(id)
+	if err != nil {
+		return Board{}, err
+	}
+	board, err := ParseFEN(fen)
+	if err != nil {
+		return Board{}, err
+	}
+	board.Chess960 = true
+	return board, nil
+}
@@ -0,0 +1,104 @@
+package chess
+
+import "testing"
+
+// TestChess960FEN checks some numbered starting positions and that all 960
+// are different valid positions.
+func TestChess960FEN(t *testing.T) {
+	// Parameters
+	expected := map[int]string{
+		0:   "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1",
+		1:   "bqnbnrkr/pppppppp/8/8/8/8/PPPPPPPP/BQNBNRKR w KQkq - 0 1",
+		518: StartingFEN,
+		959: "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w KQkq - 0 1",
+	}
+
+	// Test
+	for id, want := range expected {
+		if result, _ := Chess960FEN(id); result != want {
+			t.Errorf(`Chess960FEN(%d) = %s want match for %s`, id, result, want)
+		}
+	}
+	seen := map[string]bool{}
+	for id := 0; id < Chess960Positions; id++ {
+		board, err := ParseChess960(id)
+		if err != nil {
+			t.Fatalf(`ParseChess960(%d) = %v want match for nil`, id, err)
+		}
+		if !board.Chess960 {
+			t.Errorf(`ParseChess960(%d).Chess960 = false want match for true`, id)
+		}
+		seen[board.FEN()] = true
+	}
+	if len(seen) != Chess960Positions {
+		t.Errorf(`Chess960FEN made %d different positions want match for %d`, len(seen), Chess960Positions)
+	}
+	if _, err := Chess960FEN(Chess960Positions); err == nil {
+		t.Errorf(`Chess960FEN(%d) = nil want match for an error`, Chess960Positions)
+	}
+}
+
+// TestChess960Castling castles on boards where the king and rook start away
+// from the standard files, checking the UCI moves and resulting positions.
+func TestChess960Castling(t *testing.T) {
+	// Parameters
+	expected := []struct {
+		fen    string
+		uci    string
+		result string
+	}{
+		// the king castles onto the square of its own rook
+		{"1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R3KR1 w GBgb - 0 1", "f1g1", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 b kq - 1 1"},
+		{"1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R3KR1 w GBgb - 0 1", "f1b1", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/2KR2R1 b kq - 1 1"},
+		// the king does not move, only the rook
+		{"4r1kr/pppppppp/8/8/8/8/PPPPPPPP/4R1KR b HEhe - 0 1", "g8h8", "4rrk1/pppppppp/8/8/8/8/PPPPPPPP/4R1KR w KQ - 1 2"},
+		{"4r1kr/pppppppp/8/8/8/8/PPPPPPPP/4R1KR b HEhe - 0 1", "g8e8", "2kr3r/pppppppp/8/8/8/8/PPPPPPPP/4R1KR w KQ - 1 2"},
+		// castling with the inner of two rooks
+		{"1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w GBgb - 0 1", "c1g1", "1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1R3RKR b gq - 1 1"},
+	}
+
+	// Test
+	for _, castle := range expected {
+		board := LoadBoardFromFEN(castle.fen)
+		if !board.Chess960 {
+			t.Errorf(`LoadBoardFromFEN("%s").Chess960 = false want match for true`, castle.fen)
+		}
+		move, err := ParseUCIMove(board, castle.uci)
+		if err != nil {
+			t.Errorf(`ParseUCIMove("%s", %s) = %v want match for nil`, castle.fen, castle.uci, err)
+			continue
+		}
+		if !move.IsCastle() || MoveToUCI(board, move) != castle.uci {
+			t.Errorf(`MoveToUCI("%s", %v) = %s want match for castle %s`, castle.fen, move, MoveToUCI(board, move), castle.uci)
+		}
+		checkUnmakeMove(t, &board, 2)
+		board.PlayMove(move)
+		if result := board.FEN(); result != castle.result {
+			t.Errorf(`PlayMove("%s", %s) = %s want match for %s`, castle.fen, castle.uci, result, castle.result)
+		}
+	}
+}
+
+// TestChess960CastlingFEN checks that Shredder-FEN and X-FEN castling fields
+// load and are written back as X-FEN.
+func TestChess960CastlingFEN(t *testing.T) {
+	// Parameters
+	expected := map[string]string{
+		"4r1kr/pppppppp/8/8/8/8/PPPPPPPP/4R1KR b HEhe - 0 1":   "4r1kr/pppppppp/8/8/8/8/PPPPPPPP/4R1KR b KQkq - 0 1",
+		"1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w GBgb - 0 1": "1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w GQgq - 0 1",
+		"1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w GQgq - 0 1": "1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w GQgq - 0 1",
+		"1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w Hb - 0 1":   "1rk3rr/pppppppp/8/8/8/8/PPPPPPPP/1RK3RR w Kq - 0 1",
+	}
+
+	// Test
+	for fen, want := range expected {
+		board, err := ParseFEN(fen)
+		if err != nil {
+			t.Errorf(`ParseFEN("%s") = %v want match for nil`, fen, err)
+			continue
+		}
+		if result := board.FEN(); result != want {
+			t.Errorf(`FEN("%s") = %s want match for %s`, fen, result, want)
+		}
+	}
+}
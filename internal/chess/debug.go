@@ -1,6 +1,17 @@
 package chess
 
-import "fmt"
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DATA DEFINITIONS
 
 var expectedPerft = map[int]int{
 	0: 1,
@@ -12,14 +23,44 @@ var expectedPerft = map[int]int{
 	6: 119060324,
 }
 
+// The number of nodes reached at a depth below a single root move
+type PerftDivision struct {
+	Move  Move
+	Nodes int
+}
+
+// Options for RunPerft
+type PerftOptions struct {
+	// The number of goroutines the root moves are split across, one if < 1
+	Workers int
+	// The size of the table caching subtree counts by position, none if < 1
+	HashMB int
+}
+
+// The node count of a perft run and how long it took
+type PerftResult struct {
+	Nodes    int
+	Duration time.Duration
+}
+
+// A position from a perft test suite with the expected node count at each depth
+type PerftPosition struct {
+	FEN      string
+	Expected map[int]int
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
 // Prints the Perft results to the console for all depth values <= maxDepth
 func PrintPerftResults(maxDepth int) {
+	board := LoadBoardFromFEN(StartingFEN)
+	options := PerftOptions{Workers: runtime.NumCPU()}
 	passed := true
 	for depth := 0; depth <= maxDepth; depth++ {
-		result := Perft(depth)
+		result := RunPerft(board, depth, options)
 		expected := expectedPerft[depth]
-		fmt.Printf("Depth: %d, Result: %d, Expected %d\n", depth, result, expected)
-		if result != expected {
+		fmt.Printf("Depth: %d, Result: %d, Expected %d, Nodes/s %d\n", depth, result.Nodes, expected, result.NodesPerSecond())
+		if result.Nodes != expected {
 			passed = false
 		}
 	}
@@ -32,25 +73,161 @@ func PrintPerftResults(maxDepth int) {
 
 }
 
-// Returns the number of nodes reached at a specified depth
-func Perft(depth int) int {
-	board := LoadBoardFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+// Returns the number of nodes reached from the board at a specified depth
+func Perft(board Board, depth int) int {
+	return perft(&board, depth, nil)
+}
+
+// Returns the number of nodes reached from the board at a specified depth,
+// timing the run, splitting the root moves across the option's workers and
+// caching transposed subtrees if the option's hash table is enabled
+func RunPerft(board Board, depth int, options PerftOptions) PerftResult {
+	start := time.Now()
+	var table *perftTable
+	if options.HashMB > 0 {
+		table = newPerftTable(options.HashMB)
+	}
+
+	if options.Workers <= 1 || depth < 2 {
+		nodes := perft(&board, depth, table)
+		return PerftResult{Nodes: nodes, Duration: time.Since(start)}
+	}
 
-	return perft(board, depth)
+	moves := GetAllLegalMoves(board)
+	jobs := make(chan Move, len(moves))
+	for _, move := range moves {
+		jobs <- move
+	}
+	close(jobs)
 
+	// each worker plays its moves on its own copy of the board
+	var nodes atomic.Int64
+	var wg sync.WaitGroup
+	for range min(options.Workers, len(moves)) {
+		wg.Add(1)
+		go func(board Board) {
+			defer wg.Done()
+			for move := range jobs {
+				undo := board.PlayMove(move)
+				nodes.Add(int64(perft(&board, depth-1, table)))
+				board.UnmakeMove(move, undo)
+			}
+		}(board.Copy())
+	}
+	wg.Wait()
+
+	return PerftResult{Nodes: int(nodes.Load()), Duration: time.Since(start)}
 }
 
-func perft(board Board, depth int) int {
+// Returns the number of nodes searched per second, 0 if no time was measured
+func (result PerftResult) NodesPerSecond() int {
+	if result.Duration <= 0 {
+		return 0
+	}
+	return int(float64(result.Nodes) / result.Duration.Seconds())
+}
+
+// Returns the number of nodes reached at a specified depth below each legal
+// move, sorted by the moves' UCI strings
+func PerftDivide(board Board, depth int) []PerftDivision {
+	var divisions []PerftDivision
+	if depth < 1 {
+		return divisions
+	}
+
+	for _, move := range GetAllLegalMoves(board) {
+		undo := board.PlayMove(move)
+		divisions = append(divisions, PerftDivision{Move: move, Nodes: perft(&board, depth-1, nil)})
+		board.UnmakeMove(move, undo)
+	}
+
+	slices.SortFunc(divisions, func(a, b PerftDivision) int {
+		return strings.Compare(MoveToAlgebraic(a.Move), MoveToAlgebraic(b.Move))
+	})
+	return divisions
+}
+
+// Prints the node count below each root move and the total, in the same
+// format as Stockfish's "go perft" so that the two can be diffed
+func PrintPerftDivide(board Board, depth int) {
+	total := 0
+	for _, division := range PerftDivide(board, depth) {
+		fmt.Printf("%s: %d\n", MoveToAlgebraic(division.Move), division.Nodes)
+		total += division.Nodes
+	}
+	fmt.Printf("\nNodes searched: %d\n", total)
+}
+
+// Loads perft positions from EPD lines in the form "<fen> ;D1 20 ;D2 400"
+// Blank lines and lines starting with # are skipped
+func ParsePerftSuite(epd string) ([]PerftPosition, error) {
+	var positions []PerftPosition
+
+	for number, line := range strings.Split(epd, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ";")
+		position := PerftPosition{FEN: strings.TrimSpace(fields[0]), Expected: map[int]int{}}
+		if _, err := ParseFEN(position.FEN); err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+
+		for _, field := range fields[1:] {
+			parts := strings.Fields(field)
+			if len(parts) != 2 || !strings.HasPrefix(parts[0], "D") {
+				return nil, fmt.Errorf("line %d: %q is not in the form D<depth> <nodes>", number+1, field)
+			}
+			depth, err := strconv.Atoi(parts[0][1:])
+			if err != nil || depth < 0 {
+				return nil, fmt.Errorf("line %d: invalid depth %q", number+1, parts[0])
+			}
+			nodes, err := strconv.Atoi(parts[1])
+			if err != nil || nodes < 0 {
+				return nil, fmt.Errorf("line %d: invalid node count %q", number+1, parts[1])
+			}
+			position.Expected[depth] = nodes
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Counts the leaf nodes at the given depth by making and unmaking moves in place
+// The moves at depth 1 are counted without being played, and deeper subtrees
+// are cached in the table unless it is nil
+func perft(board *Board, depth int, table *perftTable) int {
 	nodes := 0
 
 	if depth == 0 {
 		return 1
 	}
 
-	for _, move := range GetAllLegalMoves(board) {
-		boardCopy := board.Copy()
-		boardCopy.PlayMove(move)
-		nodes += perft(boardCopy, depth-1)
+	if table != nil && depth > 1 {
+		if cached, ok := table.probe(board.Hash(), depth); ok {
+			return cached
+		}
+	}
+
+	moves := GetAllLegalMoves(*board)
+	if depth == 1 {
+		return len(moves)
+	}
+
+	for _, move := range moves {
+		undo := board.PlayMove(move)
+		nodes += perft(board, depth-1, table)
+		board.UnmakeMove(move, undo)
+	}
+
+	if table != nil {
+		table.store(board.Hash(), depth, nodes)
 	}
 
 	return nodes
@@ -0,0 +1,395 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DATA DEFINITIONS
+
+// The FEN string of the standard starting position
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// The reasons a FEN string can be rejected, wrapped in a *FENError
+var (
+	ErrFENFieldCount     = errors.New("wrong number of fields")
+	ErrFENPlacement      = errors.New("invalid piece placement")
+	ErrFENKings          = errors.New("each color needs exactly one king")
+	ErrFENPawnOnBackRank = errors.New("pawn on the first or last rank")
+	ErrFENActiveColor    = errors.New("invalid active color")
+	ErrFENInactiveCheck  = errors.New("the color not to move is in check")
+	ErrFENCastling       = errors.New("invalid castling rights")
+	ErrFENEnPassant      = errors.New("invalid en passant square")
+	ErrFENMoveCounter    = errors.New("invalid move counter")
+)
+
+// An error describing why a FEN string could not be loaded
+// Use errors.Is with the ErrFEN values to check the reason
+type FENError struct {
+	FEN    string
+	Reason error
+	Detail string
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Loads a board from a FEN string, panicking if it is invalid
+func LoadBoardFromFEN(fen string) Board {
+	board, err := ParseFEN(fen)
+	if err != nil {
+		panic(err.Error())
+	}
+	return board
+}
+
+// Loads a board from a FEN string, returning a *FENError if it is invalid
+// The move counters may be left off, defaulting to "0 1"
+// The castling field may be written as in X-FEN or Shredder-FEN, and the
+// board is set to Chess960 if a right castles with the king or rook away
+// from their standard positions
+func ParseFEN(fen string) (Board, error) {
+	fail := func(reason error, format string, args ...any) (Board, error) {
+		return Board{}, &FENError{FEN: fen, Reason: reason, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	// splitting the FEN into parts
+	parts := strings.Fields(fen)
+	if len(parts) == 4 {
+		parts = append(parts, "0", "1")
+	}
+	if len(parts) != 6 {
+		return fail(ErrFENFieldCount, "found %d fields, expected 6", len(parts))
+	}
+
+	board := Board{}
+
+	// adding the pieces
+	ranks := strings.Split(parts[0], "/")
+	if len(ranks) != 8 {
+		return fail(ErrFENPlacement, "found %d ranks, expected 8", len(ranks))
+	}
+	for index, rankString := range ranks {
+		rank := 8 - index
+		file := 1
+		previousDigit := false
+		for _, char := range rankString {
+			if file > 8 {
+				return fail(ErrFENPlacement, "rank %d has more than 8 files", rank)
+			}
+
+			if char > '0' && char < '9' {
+				// empty squares are counted by a single digit
+				if previousDigit {
+					return fail(ErrFENPlacement, "rank %d has adjacent digits", rank)
+				}
+				previousDigit = true
+				file += int(char - '0')
+				continue
+			}
+			previousDigit = false
+
+			pieceType, ok := symbolPieceTypes[unicode.ToLower(char)]
+			if !ok {
+				return fail(ErrFENPlacement, "%q is not a piece", char)
+			}
+			color := White
+			if unicode.IsLower(char) {
+				color = Black
+			}
+			if pieceType == Pawn && (rank == 1 || rank == 8) {
+				return fail(ErrFENPawnOnBackRank, "pawn on %s", PosToAlgebraic(CreatePos(rank, file)))
+			}
+			board.Add(CreatePos(rank, file), CreatePiece(color|pieceType))
+			file++
+		}
+		if file != 9 {
+			return fail(ErrFENPlacement, "rank %d has %d files, expected 8", rank, file-1)
+		}
+	}
+
+	for _, color := range [2]int{White, Black} {
+		kings := board.Bitboards[GetBitboardIndex(CreatePiece(King|color))]
+		if count := bits.OnesCount64(kings); count != 1 {
+			return fail(ErrFENKings, "found %d %s kings", count, colorNames[color])
+		}
+	}
+
+	switch parts[1] {
+	case "w":
+		board.ActiveColor = White
+	case "b":
+		board.ActiveColor = Black
+	default:
+		return fail(ErrFENActiveColor, "%q is not w or b", parts[1])
+	}
+
+	if board.isKingAttacked(oppositeColor(board.ActiveColor)) {
+		return fail(ErrFENInactiveCheck, "%s king can be captured", colorNames[oppositeColor(board.ActiveColor)])
+	}
+
+	if err := parseCastling(&board, parts[2]); err != nil {
+		return fail(ErrFENCastling, "%s", err.Error())
+	}
+
+	if parts[3] != "-" {
+		pos, err := ParsePos(parts[3])
+		if err != nil {
+			return fail(ErrFENEnPassant, "%s", err.Error())
+		}
+		if err := checkEnPassant(&board, pos); err != nil {
+			return fail(ErrFENEnPassant, "%s", err.Error())
+		}
+		board.EnPassant = &pos
+	}
+
+	halfMoves, err := strconv.Atoi(parts[4])
+	if err != nil || halfMoves < 0 {
+		return fail(ErrFENMoveCounter, "half moves %q is not a number >= 0", parts[4])
+	}
+	fullMoves, err := strconv.Atoi(parts[5])
+	if err != nil || fullMoves < 1 {
+		return fail(ErrFENMoveCounter, "full moves %q is not a number >= 1", parts[5])
+	}
+	board.HalfMoves = halfMoves
+	board.FullMoves = fullMoves
+
+	board.hash = board.calcHash()
+
+	return board, nil
+}
+
+// Returns the canonical FEN string of the board
+// Castling rights are ordered KQkq and only given while the king and rook are
+// at home, and the en passant square is only given when an en passant capture
+// is legal
+// A Chess960 right castling with a rook that is not the outermost on its
+// side is written as the rook's file, as in X-FEN
+func (board *Board) FEN() string {
+	var builder strings.Builder
+
+	for rank := 8; rank >= 1; rank-- {
+		empty := 0
+		for file := 1; file <= 8; file++ {
+			piece := board.Get(CreatePos(rank, file))
+			if piece == None {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				builder.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			symbol := PieceSymbol[piece.Type()]
+			if piece.IsWhite() {
+				symbol = strings.ToUpper(symbol)
+			}
+			builder.WriteString(symbol)
+		}
+		if empty > 0 {
+			builder.WriteString(strconv.Itoa(empty))
+		}
+		if rank > 1 {
+			builder.WriteByte('/')
+		}
+	}
+
+	if board.ActiveColor == White {
+		builder.WriteString(" w ")
+	} else {
+		builder.WriteString(" b ")
+	}
+
+	castling := ""
+	for _, right := range castlingRights {
+		if strings.ContainsRune(board.Castling, right) && checkCastlingRight(board, right) == nil {
+			castling += board.castlingSymbol(right)
+		}
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	builder.WriteString(castling)
+
+	enPassant := "-"
+	if board.EnPassant != nil && board.hasLegalEnPassant() {
+		enPassant = PosToAlgebraic(*board.EnPassant)
+	}
+	builder.WriteString(" " + enPassant)
+
+	builder.WriteString(" " + strconv.Itoa(board.HalfMoves) + " " + strconv.Itoa(board.FullMoves))
+
+	return builder.String()
+}
+
+// Returns the error message including the rejected FEN
+func (err *FENError) Error() string {
+	return fmt.Sprintf("Cannot load FEN position %q due to %s: %s", err.FEN, err.Reason, err.Detail)
+}
+
+// Returns the reason so that errors.Is can match the ErrFEN values
+func (err *FENError) Unwrap() error {
+	return err.Reason
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+var colorNames = map[int]string{White: "white", Black: "black"}
+
+var symbolPieceTypes = map[rune]int{'p': Pawn, 'n': Knight, 'b': Bishop, 'r': Rook, 'q': Queen, 'k': King}
+
+// Sets the castling rights and their rook files from the castling field
+// KQkq castle with the outermost rook on that side of the king (X-FEN) and
+// file letters castle with the rook on that file (Shredder-FEN)
+func parseCastling(board *Board, field string) error {
+	board.CastlingRookFiles = [4]int{8, 1, 8, 1}
+	if field == "-" {
+		return nil
+	}
+
+	castling := ""
+	for _, char := range field {
+		right, rookFile, err := parseCastlingSymbol(board, char)
+		if err != nil {
+			return err
+		}
+		if strings.ContainsRune(castling, right) {
+			return fmt.Errorf("%q is repeated", right)
+		}
+		castling += string(right)
+		board.CastlingRookFiles[strings.IndexRune(castlingRights, right)] = rookFile
+		if err := checkCastlingRight(board, right); err != nil {
+			return err
+		}
+	}
+
+	for index, right := range castlingRights {
+		if !strings.ContainsRune(castling, right) {
+			continue
+		}
+		board.Castling += string(right)
+		king := CalcPosFromBitboard(board.Bitboards[GetBitboardIndex(CreatePiece(King|castlingRightColor(right)))])
+		if king.File != 5 || board.CastlingRookFiles[index] != [4]int{8, 1, 8, 1}[index] {
+			board.Chess960 = true
+		}
+	}
+	return nil
+}
+
+// Returns the castling right and rook file given by a castling field symbol
+func parseCastlingSymbol(board *Board, symbol rune) (rune, int, error) {
+	color := White
+	if unicode.IsLower(symbol) {
+		color = Black
+	}
+	backRank := 1
+	if color == Black {
+		backRank = 8
+	}
+	king := CalcPosFromBitboard(board.Bitboards[GetBitboardIndex(CreatePiece(King|color))])
+	if king.Rank != backRank {
+		return 0, 0, fmt.Errorf("%q needs the king on rank %d", symbol, backRank)
+	}
+	rook := CreatePiece(Rook | color)
+
+	switch unicode.ToUpper(symbol) {
+	case 'K':
+		for file := 8; file > king.File; file-- {
+			if board.Get(CreatePos(backRank, file)) == rook {
+				return symbol, file, nil
+			}
+		}
+		return 0, 0, fmt.Errorf("%q needs a rook on the kingside of the king", symbol)
+	case 'Q':
+		for file := 1; file < king.File; file++ {
+			if board.Get(CreatePos(backRank, file)) == rook {
+				return symbol, file, nil
+			}
+		}
+		return 0, 0, fmt.Errorf("%q needs a rook on the queenside of the king", symbol)
+	case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H':
+		file := int(unicode.ToUpper(symbol)-'A') + 1
+		kind := CastleKingsideFlag
+		if file < king.File {
+			kind = CastleQueensideFlag
+		}
+		return castlingRight(color, kind), file, nil
+	}
+	return 0, 0, fmt.Errorf("%q is not one of KQkq or a file", symbol)
+}
+
+// Returns the symbol of the castling right: the right itself if it castles
+// with the outermost rook on its side, the rook's file otherwise
+func (board *Board) castlingSymbol(right rune) string {
+	rookPos := board.castlingRookPos(right)
+	rook := CreatePiece(Rook | castlingRightColor(right))
+	direction := 1
+	if unicode.ToUpper(right) == 'Q' {
+		direction = -1
+	}
+	for file := rookPos.File + direction; file >= 1 && file <= 8; file += direction {
+		if board.Get(CreatePos(rookPos.Rank, file)) == rook {
+			symbol := string(FileIndexes[rookPos.File-1])
+			if castlingRightColor(right) == White {
+				symbol = strings.ToUpper(symbol)
+			}
+			return symbol
+		}
+	}
+	return string(right)
+}
+
+// Returns an error if the king or rook needed for the castling right has left
+// its starting position
+func checkCastlingRight(board *Board, right rune) error {
+	color := castlingRightColor(right)
+	rookPos := board.castlingRookPos(right)
+	king := CalcPosFromBitboard(board.Bitboards[GetBitboardIndex(CreatePiece(King|color))])
+	if king.Rank != rookPos.Rank {
+		return fmt.Errorf("%q needs the king on rank %d", right, rookPos.Rank)
+	}
+	if board.Get(rookPos) != CreatePiece(Rook|color) {
+		return fmt.Errorf("%q needs a rook on %s", right, PosToAlgebraic(rookPos))
+	}
+	if (unicode.ToUpper(right) == 'K') != (rookPos.File > king.File) {
+		return fmt.Errorf("%q needs the rook on %s on the other side of the king", right, PosToAlgebraic(rookPos))
+	}
+	return nil
+}
+
+// Returns true if the active color has a legal en passant capture
+func (board *Board) hasLegalEnPassant() bool {
+	for _, move := range GetAllLegalMoves(*board) {
+		if move.Kind() == EnPassantFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns an error if the position could not have been passed over by a
+// pawn double push from the color not to move
+func checkEnPassant(board *Board, pos Pos) error {
+	// the pawn moved from behind the square to in front of it
+	rank := 6
+	direction := -1
+	if board.ActiveColor == Black {
+		rank = 3
+		direction = 1
+	}
+	if pos.Rank != rank {
+		return fmt.Errorf("%s is not on rank %d", PosToAlgebraic(pos), rank)
+	}
+
+	pawn := CreatePiece(Pawn | oppositeColor(board.ActiveColor))
+	if board.Get(ShiftPos(pos, direction, 0)) != pawn {
+		return fmt.Errorf("%s has no pawn in front of it", PosToAlgebraic(pos))
+	}
+	if board.Get(pos) != None || board.Get(ShiftPos(pos, -direction, 0)) != None {
+		return fmt.Errorf("%s was not passed over by a pawn", PosToAlgebraic(pos))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseFENErrors checks that invalid FEN strings are rejected with the
+// matching reason instead of panicking.
+func TestParseFENErrors(t *testing.T) {
+	// Parameters
+	expected := map[string]error{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq":          ErrFENFieldCount,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1":             ErrFENPlacement,
+		"rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1":     ErrFENPlacement,
+		"rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1":   ErrFENPlacement,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1":    ErrFENPlacement,
+		"44/8/8/8/8/8/8/k3K3 w - - 0 1":                               ErrFENPlacement,
+		"4k3/71/8/8/8/8/8/4K3 w - - 0 1":                              ErrFENPlacement,
+		"rnbq1bnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQ - 0 1":      ErrFENKings,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNP w kq - 0 1":      ErrFENPawnOnBackRank,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1":    ErrFENActiveColor,
+		"rnbqkbnr/pppp1ppp/8/8/8/8/PPPPQPPP/RNB1KBNR w KQkq - 0 1":    ErrFENInactiveCheck,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1":    ErrFENCastling,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KKkq - 0 1":    ErrFENCastling,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAhb - 0 1":    ErrFENCastling,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KHkq - 0 1":    ErrFENCastling,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3 0 1":   ErrFENEnPassant,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e6 0 1": ErrFENEnPassant,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e9 0 1": ErrFENEnPassant,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1":   ErrFENMoveCounter,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 zero": ErrFENMoveCounter,
+	}
+
+	// Test
+	for fen, reason := range expected {
+		_, err := ParseFEN(fen)
+		if !errors.Is(err, reason) {
+			t.Errorf(`ParseFEN("%s") = %v want match for %v`, fen, err, reason)
+		}
+	}
+}
+
+// TestParseFENValid checks that valid FEN strings load, including ones
+// without the move counters.
+func TestParseFENValid(t *testing.T) {
+	// Parameters
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -",
+	}
+
+	// Test
+	for _, fen := range fens {
+		if _, err := ParseFEN(fen); err != nil {
+			t.Errorf(`ParseFEN("%s") = %v want match for nil`, fen, err)
+		}
+	}
+}
+
+// TestFEN checks the canonical FEN of some simple positions.
+func TestFEN(t *testing.T) {
+	// Parameters
+	expected := map[string]string{
+		// unchanged
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1": "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		// en passant square with no pawn to capture is dropped
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+		// en passant square with a capture is kept
+		"rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3": "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3",
+		// castling rights are ordered
+		"r3k2r/8/8/8/8/8/8/R3K2R w qkQ - 4 20": "r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 4 20",
+	}
+
+	// Test
+	for fen, want := range expected {
+		board := LoadBoardFromFEN(fen)
+		if result := board.FEN(); result != want {
+			t.Errorf(`FEN("%s") = %s want match for %s`, fen, result, want)
+		}
+	}
+}
+
+// TestFENRoundTrip checks that loading the FEN of every position in perft
+// trees gives back the same position.
+func TestFENRoundTrip(t *testing.T) {
+	// Parameters
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	}
+	depth := 3
+
+	// Test
+	for _, fen := range fens {
+		board := LoadBoardFromFEN(fen)
+		checkFENRoundTrip(t, &board, depth)
+	}
+}
+
+func checkFENRoundTrip(t *testing.T, board *Board, depth int) {
+	fen := board.FEN()
+	loaded, err := ParseFEN(fen)
+	if err != nil {
+		t.Fatalf(`ParseFEN("%s") = %v want match for nil`, fen, err)
+	}
+	if loaded.Bitboards != board.Bitboards || loaded.FEN() != fen {
+		t.Fatalf(`ParseFEN("%s").FEN() = %s want match for %s`, fen, loaded.FEN(), fen)
+	}
+
+	if depth == 0 {
+		return
+	}
+	for _, move := range GetAllLegalMoves(*board) {
+		undo := board.PlayMove(move)
+		checkFENRoundTrip(t, board, depth-1)
+		board.UnmakeMove(move, undo)
+	}
+}
@@ -1,42 +1,192 @@
 package chess
 
-import "strconv"
-
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
+// Returns the move in UCI long algebraic notation such as e2e4 or e7e8q
 func MoveToAlgebraic(move Move) string {
-	return PosToAlgebraic(move.Start) + PosToAlgebraic(move.End)
+	algebraic := PosToAlgebraic(move.Start) + PosToAlgebraic(move.End)
+	if promotion, ok := promotionPieceTypes[move.Kind()]; ok {
+		algebraic += PieceSymbol[promotion]
+	}
+	return algebraic
+}
+
+// Returns the move in UCI long algebraic notation for the board it is played
+// on, which writes a Chess960 castle as the king taking its own rook (the
+// UCI_Chess960 option) and every other move as MoveToAlgebraic does
+func MoveToUCI(board Board, move Move) string {
+	if board.Chess960 && move.IsCastle() {
+		rookStart, _ := board.castlingRookMove(move)
+		return PosToAlgebraic(move.Start) + PosToAlgebraic(rookStart)
+	}
+	return MoveToAlgebraic(move)
+}
+
+// Returns the legal move on the board written in UCI long algebraic notation
+// The flag is taken from the matching legal move, so e1g1 is a castle and
+// e7e8q a promotion (the promotion letter is required)
+// Castles on a Chess960 board are written as the king taking its own rook
+func ParseUCIMove(board Board, uci string) (Move, error) {
+	if len(uci) != 4 && len(uci) != 5 {
+		return Move{}, fmt.Errorf("Cannot parse UCI move %q: expected 4 or 5 characters", uci)
+	}
+	start, err := ParsePos(uci[0:2])
+	if err != nil {
+		return Move{}, fmt.Errorf("Cannot parse UCI move %q: %w", uci, err)
+	}
+	if _, err := ParsePos(uci[2:4]); err != nil {
+		return Move{}, fmt.Errorf("Cannot parse UCI move %q: %w", uci, err)
+	}
+
+	for _, move := range GetAllLegalMoves(board) {
+		if move.Start == start && MoveToUCI(board, move) == uci {
+			return move, nil
+		}
+	}
+	return Move{}, fmt.Errorf("Cannot parse UCI move %q: it is not a legal move", uci)
 }
 
 func PosToAlgebraic(pos Pos) string {
 	rankString := strconv.Itoa(pos.Rank)
-	fileString := string(FileIndexes[pos.File - 1])
+	fileString := string(FileIndexes[pos.File-1])
 	return fileString + rankString
 }
 
-// Load an algebraic chess position into a Pos
+// Load an algebraic chess position into a Pos, panicking if it is invalid
 func LoadPos(algebraic string) Pos {
-	callPanic := func() {
-		panic("Cannot load an algebraic position " + algebraic)
+	pos, err := ParsePos(algebraic)
+	if err != nil {
+		panic(err.Error())
 	}
+	return pos
+}
 
+// Parse an algebraic chess position such as e4 into a Pos
+func ParsePos(algebraic string) (Pos, error) {
 	if len(algebraic) != 2 {
-		callPanic()
-	}
-	fileChar := rune(algebraic[0])
-	var file int
-	found := false
-	for index, char := range FileIndexes {
-		if fileChar == char {
-			file = index + 1
-			found = true
-			break
+		return Pos{}, fmt.Errorf("Cannot load an algebraic position %q", algebraic)
+	}
+
+	file := strings.IndexRune(string(FileIndexes[:]), rune(algebraic[0])) + 1
+	rank := int(algebraic[1] - '0')
+	if file < 1 || rank < 1 || rank > 8 {
+		return Pos{}, fmt.Errorf("Cannot load an algebraic position %q", algebraic)
+	}
+
+	return CreatePos(rank, file), nil
+}
+
+// Returns the move in Standard Algebraic Notation such as Nbd7, exd5, e8=Q,
+// O-O or Qh4#
+// The move must be legal on the given board
+func MoveToSAN(board Board, move Move) string {
+	san := moveToSANWithoutSuffix(board, move)
+
+	undo := board.PlayMove(move)
+	if IsKingInCheck(board) {
+		if len(GetAllLegalMoves(board)) == 0 {
+			san += "#"
+		} else {
+			san += "+"
 		}
 	}
-	if !found {
-		callPanic()
+	board.UnmakeMove(move, undo)
+
+	return san
+}
+
+// Returns the legal move on the board written in Standard Algebraic Notation
+// Check and annotation suffixes (+ # ! ?) are optional, castling may be
+// written with zeros and the = of a promotion may be left out
+func ParseSAN(board Board, san string) (Move, error) {
+	text := strings.TrimRight(san, "+#!?")
+	text = strings.ReplaceAll(text, "0", "O")
+	if length := len(text); length >= 3 && text[length-2] != '=' &&
+		strings.ContainsRune("12345678", rune(text[length-2])) &&
+		strings.ContainsRune("NBRQ", rune(text[length-1])) {
+		text = text[:length-1] + "=" + text[length-1:]
 	}
 
-	rank := int(algebraic[1] - '0')
+	var matches []Move
+	for _, move := range GetAllLegalMoves(board) {
+		if moveToSANWithoutSuffix(board, move) == text {
+			matches = append(matches, move)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Move{}, fmt.Errorf("Cannot parse SAN move %q: no legal move matches", san)
+	case 1:
+		return matches[0], nil
+	}
+	return Move{}, fmt.Errorf("Cannot parse SAN move %q: it matches %d legal moves", san, len(matches))
+}
+
+// Returns the SAN of the move without a check or checkmate suffix
+func moveToSANWithoutSuffix(board Board, move Move) string {
+	switch move.Kind() {
+	case CastleKingsideFlag:
+		return "O-O"
+	case CastleQueensideFlag:
+		return "O-O-O"
+	}
+
+	piece := board.Get(move.Start)
+	capture := board.Get(move.End) != None || move.Kind() == EnPassantFlag
+	san := ""
+
+	if piece.Type() == Pawn {
+		if capture {
+			san += string(FileIndexes[move.Start.File-1])
+		}
+	} else {
+		san += strings.ToUpper(PieceSymbol[piece.Type()])
+		san += disambiguate(board, move, piece)
+	}
 
-	return CreatePos(rank, file)
+	if capture {
+		san += "x"
+	}
+	san += PosToAlgebraic(move.End)
+
+	if promotion, ok := promotionPieceTypes[move.Kind()]; ok {
+		san += "=" + strings.ToUpper(PieceSymbol[promotion])
+	}
+
+	return san
+}
+
+// Returns the start file, rank or both needed to tell the move apart from
+// other legal moves of the same kind of piece to the same position
+func disambiguate(board Board, move Move, piece Piece) string {
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range GetAllLegalMoves(board) {
+		if other.End != move.End || other.Start == move.Start || board.Get(other.Start) != piece {
+			continue
+		}
+		ambiguous = true
+		if other.Start.File == move.Start.File {
+			sameFile = true
+		}
+		if other.Start.Rank == move.Start.Rank {
+			sameRank = true
+		}
+	}
+
+	file := string(FileIndexes[move.Start.File-1])
+	rank := strconv.Itoa(move.Start.Rank)
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return file
+	case !sameRank:
+		return rank
+	}
+	return file + rank
 }
@@ -0,0 +1,123 @@
+package chess
+
+import "testing"
+
+// TestSAN checks the SAN of moves needing captures, disambiguation,
+// promotion, castling and check suffixes, and that parsing it gives back
+// the same move.
+func TestSAN(t *testing.T) {
+	// Parameters
+	type sanCase struct {
+		fen  string
+		move Move
+		san  string
+	}
+	cases := []sanCase{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			Move{Start: LoadPos("e2"), End: LoadPos("e4"), Flag: PawnDoublePushFlag}, "e4"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			Move{Start: LoadPos("g1"), End: LoadPos("f3"), Flag: NoFlag}, "Nf3"},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			Move{Start: LoadPos("e1"), End: LoadPos("g1"), Flag: CastleKingsideFlag | BreaksCastlingRightsFlag}, "O-O"},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			Move{Start: LoadPos("e1"), End: LoadPos("c1"), Flag: CastleQueensideFlag | BreaksCastlingRightsFlag}, "O-O-O"},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			Move{Start: LoadPos("e2"), End: LoadPos("a6"), Flag: CaptureFlag, Captured: CreatePiece(Bishop | Black)}, "Bxa6"},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			Move{Start: LoadPos("d5"), End: LoadPos("e6"), Flag: CaptureFlag, Captured: CreatePiece(Pawn | Black)}, "dxe6"},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			Move{Start: LoadPos("e5"), End: LoadPos("f7"), Flag: CaptureFlag, Captured: CreatePiece(Pawn | Black)}, "Nxf7"},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			Move{Start: LoadPos("c3"), End: LoadPos("b5"), Flag: NoFlag}, "Nb5"},
+		{"4k3/8/8/8/R6R/8/8/R3K3 w - - 0 1",
+			Move{Start: LoadPos("a4"), End: LoadPos("a2"), Flag: NoFlag}, "R4a2"},
+		{"4k3/8/8/8/R6R/8/8/R3K3 w - - 0 1",
+			Move{Start: LoadPos("h4"), End: LoadPos("e4"), Flag: NoFlag}, "Rhe4+"},
+		{"6k1/8/8/8/Q2Q4/8/8/Q3K3 w - - 0 1",
+			Move{Start: LoadPos("a4"), End: LoadPos("d1"), Flag: NoFlag}, "Qa4d1"},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1",
+			Move{Start: LoadPos("b7"), End: LoadPos("b8"), Flag: PromoteToQueenFlag}, "b8=Q+"},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1",
+			Move{Start: LoadPos("b7"), End: LoadPos("b8"), Flag: PromoteToKnightFlag}, "b8=N"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			Move{Start: LoadPos("e5"), End: LoadPos("f6"), Flag: EnPassantFlag | CaptureFlag, Captured: CreatePiece(Pawn | Black)}, "exf6"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2",
+			Move{Start: LoadPos("d8"), End: LoadPos("h4"), Flag: NoFlag}, "Qh4#"},
+	}
+
+	// Test
+	for _, c := range cases {
+		board := LoadBoardFromFEN(c.fen)
+		if san := MoveToSAN(board, c.move); san != c.san {
+			t.Errorf(`MoveToSAN("%s", %s) = %s want match for %s`, c.fen, MoveToAlgebraic(c.move), san, c.san)
+		}
+		move, err := ParseSAN(board, c.san)
+		if err != nil || move != c.move {
+			t.Errorf(`ParseSAN("%s", %s) = %v, %v want match for %v`, c.fen, c.san, move, err, c.move)
+		}
+	}
+}
+
+// TestParseSANLenient checks the optional forms accepted by ParseSAN and
+// that illegal moves are rejected.
+func TestParseSANLenient(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/1P6/8/8/8/8/8/R3K2R w KQkq - 0 1"
+	expected := map[string]Move{
+		"0-0":   {Start: LoadPos("e1"), End: LoadPos("g1"), Flag: CastleKingsideFlag | BreaksCastlingRightsFlag},
+		"O-O-O": {Start: LoadPos("e1"), End: LoadPos("c1"), Flag: CastleQueensideFlag | BreaksCastlingRightsFlag},
+		"bxa8Q": {Start: LoadPos("b7"), End: LoadPos("a8"), Flag: PromoteToQueenFlag | CaptureFlag | BreaksCastlingRightsFlag, Captured: CreatePiece(Rook | Black)},
+		"b8=R":  {Start: LoadPos("b7"), End: LoadPos("b8"), Flag: PromoteToRookFlag},
+		"Rxh8!": {Start: LoadPos("h1"), End: LoadPos("h8"), Flag: CaptureFlag | BreaksCastlingRightsFlag, Captured: CreatePiece(Rook | Black)},
+	}
+	invalid := []string{"e4", "Kd3", "Rb1b2", "hello"}
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	for san, want := range expected {
+		move, err := ParseSAN(board, san)
+		if err != nil || move != want {
+			t.Errorf(`ParseSAN("%s", %s) = %v, %v want match for %v`, fen, san, move, err, want)
+		}
+	}
+	for _, san := range invalid {
+		if _, err := ParseSAN(board, san); err == nil {
+			t.Errorf(`ParseSAN("%s", %s) = nil want match for an error`, fen, san)
+		}
+	}
+}
+
+// TestParseUCIMove checks that UCI moves get the flag of the matching legal
+// move and that illegal or malformed moves are rejected.
+func TestParseUCIMove(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/1P6/8/3pP3/8/8/6P1/R3K2R w KQkq d6 0 1"
+	expected := map[string]Move{
+		"e1g1":  {Start: LoadPos("e1"), End: LoadPos("g1"), Flag: CastleKingsideFlag | BreaksCastlingRightsFlag},
+		"e1c1":  {Start: LoadPos("e1"), End: LoadPos("c1"), Flag: CastleQueensideFlag | BreaksCastlingRightsFlag},
+		"e5d6":  {Start: LoadPos("e5"), End: LoadPos("d6"), Flag: EnPassantFlag | CaptureFlag, Captured: CreatePiece(Pawn | Black)},
+		"g2g4":  {Start: LoadPos("g2"), End: LoadPos("g4"), Flag: PawnDoublePushFlag},
+		"b7a8q": {Start: LoadPos("b7"), End: LoadPos("a8"), Flag: PromoteToQueenFlag | CaptureFlag | BreaksCastlingRightsFlag, Captured: CreatePiece(Rook | Black)},
+		"b7b8n": {Start: LoadPos("b7"), End: LoadPos("b8"), Flag: PromoteToKnightFlag},
+		"e1d1":  {Start: LoadPos("e1"), End: LoadPos("d1"), Flag: BreaksCastlingRightsFlag},
+	}
+	invalid := []string{"b7a8", "e2e4", "e1e3", "e1g1q", "z9a1", "e1"}
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	for uci, want := range expected {
+		move, err := ParseUCIMove(board, uci)
+		if err != nil || move != want {
+			t.Errorf(`ParseUCIMove("%s", %s) = %v, %v want match for %v`, fen, uci, move, err, want)
+			continue
+		}
+		if algebraic := MoveToAlgebraic(move); algebraic != uci {
+			t.Errorf(`MoveToAlgebraic(%v) = %s want match for %s`, move, algebraic, uci)
+		}
+	}
+	for _, uci := range invalid {
+		if _, err := ParseUCIMove(board, uci); err == nil {
+			t.Errorf(`ParseUCIMove("%s", %s) = nil want match for an error`, fen, uci)
+		}
+	}
+}
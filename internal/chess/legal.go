@@ -0,0 +1,227 @@
+package chess
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// DATA DEFINITIONS
+
+// The kinds of legal moves that GenerateMoves adds to a list
+type GenerationMode int
+
+const (
+	// Every legal move
+	AllMoves GenerationMode = iota
+	// Captures (including en passant) and promotions (including quiet ones)
+	CapturesAndPromotions
+	// Every legal move that is not a capture or promotion, including castling
+	QuietMoves
+	// The quiet moves that give check
+	QuietChecks
+	// Every legal move while the king is in check, none otherwise
+	Evasions
+)
+
+// The checks and pins on the active color's king, found once per position so
+// that only legal moves are generated
+type kingSafety struct {
+	king     int        // square of the active color's king
+	checkers uint64     // enemy pieces giving check
+	targets  uint64     // squares a piece other than the king may move to
+	pinned   uint64     // own pieces that shield the king from a slider
+	pinRays  [64]uint64 // for each pinned square, the squares it may move to
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Adds the legal moves of the given kind for the active color to the list,
+// after any moves already in it, without playing any of them
+// Moves are limited by the checkers and pins of the position, and only the
+// king's moves and en passant are tested square by square
+func GenerateMoves(board *Board, mode GenerationMode, list *MoveList) {
+	safety := board.calcKingSafety()
+	if mode == Evasions && safety.checkers == 0 {
+		return
+	}
+
+	start := list.Count
+	captures := mode != QuietMoves && mode != QuietChecks
+	quiets := mode != CapturesAndPromotions
+
+	own := board.colorOccupancy(board.ActiveColor)
+	enemies := board.colorOccupancy(oppositeColor(board.ActiveColor))
+	occupancy := board.Occupancy
+
+	// the squares each kind of move may land on
+	var landings uint64
+	if captures {
+		landings |= enemies
+	}
+	if quiets {
+		landings |= ^occupancy
+	}
+
+	// the king may not step to an attacked square, including one that it
+	// shields from a slider
+	kingPos := BitboardShiftsToPos(safety.king)
+	withoutKing := occupancy &^ (uint64(1) << safety.king)
+	for targets := kingAttacks[safety.king] &^ own & landings; targets != 0; targets &= targets - 1 {
+		square := bits.TrailingZeros64(targets)
+		if board.attackersOf(square, withoutKing)&enemies == 0 {
+			list.Add(createMove(board, kingPos, BitboardShiftsToPos(square), NoFlag))
+		}
+	}
+
+	// only the king can move out of a double check
+	if bits.OnesCount64(safety.checkers) > 1 {
+		board.keepChecks(list, start, mode)
+		return
+	}
+
+	if safety.checkers == 0 && quiets {
+		for _, kind := range [2]int{CastleKingsideFlag, CastleQueensideFlag} {
+			if strings.ContainsRune(board.Castling, castlingRight(board.ActiveColor, kind)) {
+				attachCastle(board, kingPos, kind, list)
+			}
+		}
+	}
+
+	for pieceType := Pawn; pieceType < King; pieceType++ {
+		bitboard := board.Bitboards[GetBitboardIndex(CreatePiece(pieceType|board.ActiveColor))]
+		for bitboard != 0 {
+			square := bits.TrailingZeros64(bitboard)
+			bitboard &= bitboard - 1
+			pos := BitboardShiftsToPos(square)
+
+			allowed := safety.targets
+			if safety.pinned&(uint64(1)<<square) != 0 {
+				allowed &= safety.pinRays[square]
+			}
+
+			switch pieceType {
+			case Pawn:
+				attachLegalPawnMoves(board, pos, list, allowed, safety.king, captures, quiets)
+			case Knight:
+				attachBitboardMoves(board, pos, list, knightAttacks[square]&landings&allowed, NoFlag)
+			case Rook:
+				attachBitboardMoves(board, pos, list, rookAttacks(square, occupancy)&landings&allowed, NoFlag)
+			case Bishop:
+				attachBitboardMoves(board, pos, list, bishopAttacks(square, occupancy)&landings&allowed, NoFlag)
+			case Queen:
+				attachBitboardMoves(board, pos, list, queenAttacks(square, occupancy)&landings&allowed, NoFlag)
+			}
+		}
+	}
+
+	board.keepChecks(list, start, mode)
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Returns the checkers of the active color's king, the squares that block or
+// capture a single checker, and the pieces pinned to the king
+func (board *Board) calcKingSafety() kingSafety {
+	var safety kingSafety
+	color := board.ActiveColor
+	enemy := oppositeColor(color)
+	enemies := board.colorOccupancy(enemy)
+	safety.king = bits.TrailingZeros64(board.Bitboards[GetBitboardIndex(CreatePiece(King|color))])
+
+	safety.checkers = board.attackersOf(safety.king, board.Occupancy) & enemies
+	safety.targets = ^uint64(0)
+	if safety.checkers != 0 {
+		checker := bits.TrailingZeros64(safety.checkers)
+		safety.targets = safety.checkers | betweenSquares[safety.king][checker]
+	}
+
+	// an enemy slider that would attack the king through exactly one of our
+	// pieces pins it to the line between them
+	queens := board.Bitboards[GetBitboardIndex(CreatePiece(Queen|enemy))]
+	rooks := board.Bitboards[GetBitboardIndex(CreatePiece(Rook|enemy))] | queens
+	bishops := board.Bitboards[GetBitboardIndex(CreatePiece(Bishop|enemy))] | queens
+	snipers := rookAttacks(safety.king, enemies)&rooks | bishopAttacks(safety.king, enemies)&bishops
+	for snipers != 0 {
+		sniper := bits.TrailingZeros64(snipers)
+		snipers &= snipers - 1
+		between := betweenSquares[safety.king][sniper] & board.Occupancy
+		if bits.OnesCount64(between) == 1 && between&board.colorOccupancy(color) != 0 {
+			safety.pinned |= between
+			safety.pinRays[bits.TrailingZeros64(between)] = betweenSquares[safety.king][sniper] | uint64(1)<<sniper
+		}
+	}
+
+	return safety
+}
+
+// Adds the legal moves of a pawn whose pushes and captures must land on the
+// allowed squares
+// Promotions are counted with the captures, other pushes with the quiets
+func attachLegalPawnMoves(board *Board, pos Pos, list *MoveList, allowed uint64, king int, captures bool, quiets bool) {
+	square := PosToBitboardShifts(pos)
+	color := board.ActiveColor
+	occupancy := board.Occupancy
+	enemies := board.colorOccupancy(oppositeColor(color))
+
+	startRank := 2
+	promoting := pos.Rank == 7
+	if color == Black {
+		startRank = 7
+		promoting = pos.Rank == 2
+	}
+
+	// pushes
+	if promoting && captures || !promoting && quiets {
+		single := calcPawnPush(CalcBitboard(pos), color) &^ occupancy
+		attachPawnBitboardMoves(board, pos, list, single&allowed, promoting)
+		if pos.Rank == startRank {
+			double := calcPawnPush(single, color) &^ occupancy
+			attachBitboardMoves(board, pos, list, double&allowed, PawnDoublePushFlag)
+		}
+	}
+
+	if !captures {
+		return
+	}
+	attachPawnBitboardMoves(board, pos, list, pawnAttacks[colorIndex(color)][square]&enemies&allowed, promoting)
+
+	// en passant removes two pawns from the board at once, which can uncover
+	// an attack on the king that no pin or check mask describes, so the
+	// position after the capture is tested directly
+	if board.EnPassant == nil {
+		return
+	}
+	target := CalcBitboard(*board.EnPassant)
+	if pawnAttacks[colorIndex(color)][square]&target == 0 {
+		return
+	}
+	captured := calcPawnPush(target, oppositeColor(color))
+	after := occupancy&^CalcBitboard(pos)&^captured | target
+	if board.attackersOf(king, after)&enemies&^captured == 0 {
+		attachBitboardMoves(board, pos, list, target, EnPassantFlag)
+	}
+}
+
+// Removes the moves added to the list since start that do not give check if
+// only quiet checks are wanted
+func (board *Board) keepChecks(list *MoveList, start int, mode GenerationMode) {
+	if mode != QuietChecks {
+		return
+	}
+	kept := start
+	for _, move := range list.Moves[start:list.Count] {
+		if board.givesCheck(move) {
+			list.Moves[kept] = move
+			kept++
+		}
+	}
+	list.Count = kept
+}
+
+// Returns true if the legal move puts the other color's king in check
+func (board *Board) givesCheck(move Move) bool {
+	undo := board.PlayMove(move)
+	check := board.isKingAttacked(board.ActiveColor)
+	board.UnmakeMove(move, undo)
+	return check
+}
@@ -0,0 +1,160 @@
+package chess
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestLegalMovesMatchFilter walks the perft tree of each suite position,
+// checking that the legal generator finds the same moves as playing every
+// pseudolegal move and filtering out those that leave the king in check.
+func TestLegalMovesMatchFilter(t *testing.T) {
+	// Parameters
+	depth := 2
+	if testing.Short() {
+		depth = 1
+	}
+
+	// Test
+	positions, err := ParsePerftSuite(perftSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range positions {
+		board := LoadBoardFromFEN(position.FEN)
+		checkLegalMoves(t, &board, depth)
+	}
+}
+
+func checkLegalMoves(t *testing.T, board *Board, depth int) {
+	legal := packMoves(GetAllLegalMoves(*board))
+	filtered := packMoves(GetMoves(*board, false, true, false))
+	if !slices.Equal(legal, filtered) {
+		t.Fatalf(`GetAllLegalMoves("%s") = %d moves want match for %d`, board.FEN(), len(legal), len(filtered))
+	}
+	if depth == 0 {
+		return
+	}
+	for _, move := range GetAllLegalMoves(*board) {
+		undo := board.PlayMove(move)
+		checkLegalMoves(t, board, depth-1)
+		board.UnmakeMove(move, undo)
+	}
+}
+
+// Returns the packed moves in ascending order so that move lists can be compared
+func packMoves(moves []Move) []uint32 {
+	packed := make([]uint32, len(moves))
+	for index, move := range moves {
+		packed[index] = move.Pack()
+	}
+	slices.Sort(packed)
+	return packed
+}
+
+// TestLegalMovesEdgeCases checks positions where checks and pins limit the
+// legal moves, including en passant captures that uncover a check.
+func TestLegalMovesEdgeCases(t *testing.T) {
+	// Parameters
+	expected := map[string]int{
+		"8/8/8/KPp4r/8/8/8/7k w - c6 0 1":    4,  // en passant exposes the king along the rank
+		"8/8/8/2k5/2pP4/8/8/5B1K b - d3 0 1": 8,  // en passant captures the checking pawn
+		"8/8/8/8/k2Pp2Q/8/8/7K b - d3 0 1":   6,  // en passant exposes the king along the rank
+		"4k3/8/8/8/8/8/4r3/R3K2R w KQ - 0 1": 3,  // check from a rook next to the king, no castling
+		"4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1":  4,  // pinned bishop may not move
+		"4k3/8/8/1b6/8/3N4/4K3/8 w - - 0 1":  7,  // pinned knight may not move
+		"4k3/8/8/8/8/5n2/3q4/4K3 w - - 0 1":  1,  // double check, only the king moves
+		"r3k3/8/8/8/8/8/8/R3K2R b KQq - 0 1": 16, // queenside castling through no check
+	}
+
+	// Test
+	for fen, want := range expected {
+		board := LoadBoardFromFEN(fen)
+		moves := GetAllLegalMoves(board)
+		if len(moves) != want {
+			t.Errorf(`len(GetAllLegalMoves("%s")) = %d want match for %d`, fen, len(moves), want)
+		}
+		if filtered := GetMoves(board, false, true, false); len(filtered) != len(moves) {
+			t.Errorf(`len(GetMoves("%s")) = %d want match for %d`, fen, len(filtered), len(moves))
+		}
+	}
+}
+
+// TestGenerateMovesModes walks the perft tree of each suite position,
+// checking that every mode generates exactly its share of the legal moves.
+func TestGenerateMovesModes(t *testing.T) {
+	// Parameters
+	depth := 2
+	if testing.Short() {
+		depth = 1
+	}
+
+	// Test
+	positions, err := ParsePerftSuite(perftSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range positions {
+		board := LoadBoardFromFEN(position.FEN)
+		checkGenerationModes(t, &board, depth)
+	}
+}
+
+func checkGenerationModes(t *testing.T, board *Board, depth int) {
+	all := GetAllLegalMoves(*board)
+	inCheck := IsKingInCheck(*board)
+
+	var wanted [5][]Move
+	wanted[AllMoves] = all
+	for _, move := range all {
+		if move.IsCapture() || move.IsPromotion() {
+			wanted[CapturesAndPromotions] = append(wanted[CapturesAndPromotions], move)
+			continue
+		}
+		wanted[QuietMoves] = append(wanted[QuietMoves], move)
+		undo := board.PlayMove(move)
+		if IsKingInCheck(*board) {
+			wanted[QuietChecks] = append(wanted[QuietChecks], move)
+		}
+		board.UnmakeMove(move, undo)
+	}
+	if inCheck {
+		wanted[Evasions] = all
+	}
+
+	var list MoveList
+	for mode, want := range wanted {
+		list.Clear()
+		GenerateMoves(board, GenerationMode(mode), &list)
+		if !slices.Equal(packMoves(list.Slice()), packMoves(want)) {
+			t.Fatalf(`GenerateMoves("%s", %d) = %d moves want match for %d`, board.FEN(), mode, list.Count, len(want))
+		}
+	}
+
+	if depth == 0 {
+		return
+	}
+	for _, move := range all {
+		undo := board.PlayMove(move)
+		checkGenerationModes(t, board, depth-1)
+		board.UnmakeMove(move, undo)
+	}
+}
+
+// TestGetMovesOnlyTaking checks that only captures are kept when taking,
+// including en passant.
+func TestGetMovesOnlyTaking(t *testing.T) {
+	// Parameters
+	fen := "4k3/8/8/3pP3/8/8/8/4K2n w - d6 0 1"
+	expected := []string{"e5d6"}
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	var result []string
+	for _, move := range GetMoves(board, false, true, true) {
+		result = append(result, MoveToAlgebraic(move))
+	}
+	if !slices.Equal(result, expected) {
+		t.Errorf(`GetMoves("%s", onlyTaking) = %v want match for %v`, fen, result, expected)
+	}
+}
@@ -1,82 +1,202 @@
 package chess
 
-import "strings"
+import (
+	"math/bits"
+	"slices"
+	"strings"
+)
 
 // DATA DEFINITIONS
 
 // Flag that is used to signal a special effect
+// The low four bits hold the kind of move, which are mutually exclusive
 const (
-	NoFlag                   = 0
-	EnPassantFlag            = 1
-	CastleKingsideFlag       = 2
-	CastleQueensideFlag      = 3
-	PromoteToQueenFlag       = 4
-	PromoteToRookFlag        = 5
-	PromoteToBishopFlag      = 6
-	PromoteToKnightFlag      = 7
-	PawnDoublePushFlag       = 8
-	BreaksCastlingRightsFlag = 9
+	NoFlag              = 0
+	EnPassantFlag       = 1
+	CastleKingsideFlag  = 2
+	CastleQueensideFlag = 3
+	PromoteToQueenFlag  = 4
+	PromoteToRookFlag   = 5
+	PromoteToBishopFlag = 6
+	PromoteToKnightFlag = 7
+	PawnDoublePushFlag  = 8
 )
 
+// Flag bits that can be combined with any kind of move
+const (
+	CaptureFlag              = 1 << 4
+	BreaksCastlingRightsFlag = 1 << 5
+)
+
+const moveKindMask = 0b1111
+
+// The piece type a pawn becomes for each promotion flag
+var promotionPieceTypes = map[int]int{
+	PromoteToQueenFlag:  Queen,
+	PromoteToRookFlag:   Rook,
+	PromoteToBishopFlag: Bishop,
+	PromoteToKnightFlag: Knight,
+}
+
+// The most moves a list can hold, more than any position has
+const MaxMoves = 256
+
 // A chess move from one square to another with special effects recorded
 type Move struct {
-	Start Pos
-	End   Pos
-	Flag  int
+	Start    Pos
+	End      Pos
+	Flag     int
+	Captured Piece
+}
+
+// A fixed capacity list of moves that generation adds to without allocating
+type MoveList struct {
+	Moves [MaxMoves]Move
+	Count int
 }
 
+/*
+Move
+
+- Flag: int
+The kind of move (one of the flags up to PawnDoublePushFlag) combined with
+CaptureFlag if a piece is captured and BreaksCastlingRightsFlag if either
+color loses a castling right, e.g. a rook capturing an unmoved rook.
+
+- Captured: Piece
+The piece captured by the move (the pawn for en passant). None if the move
+is not a capture.
+
+*/
+
 // PUBLIC FUNCTION DEFINTIONS
 
+// Returns the kind of move, the flag without its combinable bits
+func (move Move) Kind() int {
+	return move.Flag & moveKindMask
+}
+
+// Returns true if the move captures a piece
+func (move Move) IsCapture() bool {
+	return move.Flag&CaptureFlag != 0
+}
+
+// Returns true if the move promotes a pawn
+func (move Move) IsPromotion() bool {
+	_, ok := promotionPieceTypes[move.Kind()]
+	return ok
+}
+
+// Returns the piece type the pawn becomes, 0 if the move is not a promotion
+func (move Move) PromotionType() int {
+	return promotionPieceTypes[move.Kind()]
+}
+
+// Returns true if the move castles on either side
+func (move Move) IsCastle() bool {
+	return move.Kind() == CastleKingsideFlag || move.Kind() == CastleQueensideFlag
+}
+
+// Returns true if the move removes a castling right from either color
+func (move Move) BreaksCastlingRights() bool {
+	return move.Flag&BreaksCastlingRightsFlag != 0
+}
+
+// Returns the move packed into the low 22 bits of an integer: start and end
+// bitboard shifts (6 bits each), flag (6 bits) and captured piece (4 bits)
+func (move Move) Pack() uint32 {
+	return uint32(PosToBitboardShifts(move.Start)) |
+		uint32(PosToBitboardShifts(move.End))<<6 |
+		uint32(move.Flag)<<12 |
+		uint32(move.Captured)<<18
+}
+
+// Returns the move packed by Move.Pack
+func UnpackMove(packed uint32) Move {
+	return Move{
+		Start:    BitboardShiftsToPos(int(packed & 0x3f)),
+		End:      BitboardShiftsToPos(int(packed >> 6 & 0x3f)),
+		Flag:     int(packed >> 12 & 0x3f),
+		Captured: Piece(packed >> 18 & 0xf),
+	}
+}
+
+// Adds the move to the end of the list
+func (list *MoveList) Add(move Move) {
+	list.Moves[list.Count] = move
+	list.Count++
+}
+
+// Returns the moves in the list, which are overwritten when it is reused
+func (list *MoveList) Slice() []Move {
+	return list.Moves[:list.Count]
+}
+
+// Empties the list so that it can be reused
+func (list *MoveList) Clear() {
+	list.Count = 0
+}
+
+// Returns every legal move for the active color
+// The moves are generated directly from the checks and pins on the king, and
+// are the same as GetMoves(board, false, true, false) finds by playing them
 func GetAllLegalMoves(board Board) []Move {
-	return GetMoves(board, false, true, false)
+	var list MoveList
+	GenerateMoves(&board, AllMoves, &list)
+	return slices.Clone(list.Slice())
 }
 
 // GetMoves returns all possible moves for the active color
+// onlyAttacking leaves out pawn pushes and castling, checkIllegal leaves out
+// moves that put the king in check and onlyTaking leaves out non captures
+// Prefer GetAllLegalMoves or GenerateMoves, which only generate legal moves
 func GetMoves(board Board, onlyAttacking bool, checkIllegal bool, onlyTaking bool) []Move {
-	var moves []Move
-	for rank := 1; rank <= 8; rank++ {
-		for file := 1; file <= 8; file++ {
-			pos := CreatePos(rank, file)
-			piece := board.Get(pos)
-			if piece.Color() != board.ActiveColor {
-				continue
-			}
-			switch piece.Type() {
+	var list MoveList
+
+	own := board.colorOccupancy(board.ActiveColor)
+	occupancy := board.Occupancy
+
+	for pieceType := Pawn; pieceType <= King; pieceType++ {
+		bitboard := board.Bitboards[GetBitboardIndex(CreatePiece(pieceType|board.ActiveColor))]
+		for bitboard != 0 {
+			square := bits.TrailingZeros64(bitboard)
+			bitboard &= bitboard - 1
+			pos := BitboardShiftsToPos(square)
+
+			switch pieceType {
 			case Pawn:
-				moves = append(moves, getPawnMoves(board, pos, onlyAttacking)...)
+				attachPawnMoves(&board, pos, &list, onlyAttacking)
 			case Knight:
-				moves = append(moves, getKnightMoves(board, pos, NoFlag)...)
+				attachBitboardMoves(&board, pos, &list, knightAttacks[square]&^own, NoFlag)
 			case Rook:
-				moves = append(moves, getRookMoves(board, pos, BreaksCastlingRightsFlag)...)
+				attachBitboardMoves(&board, pos, &list, rookAttacks(square, occupancy)&^own, NoFlag)
 			case Bishop:
-				moves = append(moves, getBishopMoves(board, pos, NoFlag)...)
+				attachBitboardMoves(&board, pos, &list, bishopAttacks(square, occupancy)&^own, NoFlag)
 			case Queen:
-				moves = append(moves, getRookMoves(board, pos, NoFlag)...)
-				moves = append(moves, getBishopMoves(board, pos, NoFlag)...)
+				attachBitboardMoves(&board, pos, &list, queenAttacks(square, occupancy)&^own, NoFlag)
 			case King:
-				moves = append(moves, getKingMoves(board, pos, onlyAttacking)...)
+				attachKingMoves(&board, pos, &list, onlyAttacking)
 			}
-
 		}
 	}
 
-	if !checkIllegal && !onlyTaking {
-		return moves
-	}
-
 	var filteredMoves []Move
 
 	// check move legality and if take moves
-	for _, move := range moves {
-		boardCopy := board.Copy()
-		boardCopy.PlayMove(move)
-		boardCopy.changeActiveColor() // must be orginal color
-		if onlyAttacking && boardCopy.Get(move.End) == None {
+	// board is our own copy so moves can be played and unmade in place
+	for _, move := range list.Slice() {
+		if onlyTaking && !move.IsCapture() {
 			continue
 		}
-		if checkIllegal && !IsKingInCheck(boardCopy) {
-			filteredMoves = append(filteredMoves, move)
+		if checkIllegal {
+			undo := board.PlayMove(move)
+			illegal := board.isKingAttacked(oppositeColor(board.ActiveColor))
+			board.UnmakeMove(move, undo)
+			if illegal {
+				continue
+			}
 		}
+		filteredMoves = append(filteredMoves, move)
 	}
 
 	return filteredMoves
@@ -84,284 +204,138 @@ func GetMoves(board Board, onlyAttacking bool, checkIllegal bool, onlyTaking boo
 
 // Generating pseudolegal moves
 
-// Get pseudolegal pawn moves for a position on a given board
-func getPawnMoves(board Board, pos Pos, onlyAttacking bool) []Move {
-	var moves []Move
+// Adds the pseudolegal pawn moves for a position on a given board
+func attachPawnMoves(board *Board, pos Pos, list *MoveList, onlyAttacking bool) {
+	square := PosToBitboardShifts(pos)
+	occupancy := board.Occupancy
+	enemies := board.colorOccupancy(oppositeColor(board.ActiveColor))
 
-	direction := 1
+	promoting := false
+	endRank := pos.Rank + 1
+	startRank := 2
 	if board.ActiveColor == Black {
-		direction = -1
-	}
-
-	blocked := false
-
-	if !IsShiftIllegal(pos, direction, 0) {
-		if board.Get(ShiftPos(pos, direction, 0)) != None {
-			blocked = true
-		}
+		endRank = pos.Rank - 1
+		startRank = 7
 	}
-
-	flag := 0
-
-	endRank := pos.Rank + direction
-
 	if endRank == 8 || endRank == 1 {
-		flag = PromoteToQueenFlag
+		promoting = true
 	}
 
-	// single pushes
-	if !blocked {
-		attachPawnMove(board, pos, &moves, direction, 0, false, flag, onlyAttacking)
-	}
-	attachPawnMove(board, pos, &moves, direction, 1, true, flag, onlyAttacking)
-	attachPawnMove(board, pos, &moves, direction, -1, true, flag, onlyAttacking)
-
-	// double push
-
-	if !blocked {
-		endRank = pos.Rank + direction*2
-		if endRank == 5 && board.ActiveColor == Black ||
-			endRank == 4 && board.ActiveColor == White {
+	// pushes
+	if !onlyAttacking {
+		single := calcPawnPush(CalcBitboard(pos), board.ActiveColor) &^ occupancy
+		attachPawnBitboardMoves(board, pos, list, single, promoting)
 
-			attachPawnMove(board, pos, &moves, direction*2, 0,
-				false, PawnDoublePushFlag, onlyAttacking)
+		if pos.Rank == startRank {
+			double := calcPawnPush(single, board.ActiveColor) &^ occupancy
+			attachBitboardMoves(board, pos, list, double, PawnDoublePushFlag)
 		}
-
 	}
 
-	// en passant
+	// captures
+	attachPawnBitboardMoves(board, pos, list, pawnAttacks[colorIndex(board.ActiveColor)][square]&enemies, promoting)
 
+	// en passant
 	if board.EnPassant != nil {
-		enPassant := *board.EnPassant
-		if enPassant.Rank == pos.Rank+direction {
-			if enPassant.File == pos.File+1 || enPassant.File == pos.File-1 {
-				moves = append(moves, Move{Start: pos, End: enPassant, Flag: EnPassantFlag})
-			}
-		}
+		enPassant := pawnAttacks[colorIndex(board.ActiveColor)][square] & CalcBitboard(*board.EnPassant)
+		attachBitboardMoves(board, pos, list, enPassant, EnPassantFlag)
 	}
+}
 
-	return moves
+// Adds a pawn move to every position in the targets bitboard, with a move
+// for each promotion piece if the pawn is promoting
+func attachPawnBitboardMoves(board *Board, pos Pos, list *MoveList, targets uint64, promoting bool) {
+	if !promoting {
+		attachBitboardMoves(board, pos, list, targets, NoFlag)
+		return
+	}
+	for _, flag := range [4]int{PromoteToQueenFlag, PromoteToRookFlag, PromoteToBishopFlag, PromoteToKnightFlag} {
+		attachBitboardMoves(board, pos, list, targets, flag)
+	}
 }
 
-// Attempts to add the given move to the list of moves if pseudolegal
-func attachPawnMove(board Board, pos Pos, moves *[]Move, rankShift int, fileShift int, pawnAttack bool, flag int, onlyAttacking bool) {
-	if !IsShiftIllegal(pos, rankShift, fileShift) {
-		end := ShiftPos(pos, rankShift, fileShift)
-		endPiece := board.Get(end)
-		if endPiece == None && pawnAttack {
-			return
-		}
-		if endPiece != None && !pawnAttack {
-			return
-		}
-		if endPiece == None || (endPiece.Color() != board.ActiveColor) {
-			if fileShift != 0 || !onlyAttacking {
-				*moves = append(*moves, Move{Start: pos, End: end, Flag: flag})
-			}
-		}
+// Returns the bitboard pushed one rank forward for pawns of the given color
+func calcPawnPush(bitboard uint64, color int) uint64 {
+	if color == White {
+		return bitboard << 8
 	}
+	return bitboard >> 8
 }
 
-// Get pseudolegal king moves for a position on a given board
-func getKingMoves(board Board, kingPos Pos, onlyAttacking bool) []Move {
-	var moves []Move
-	attachShiftedMove(board, kingPos, &moves, 0, 1, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, 1, 0, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, 1, 1, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, 0, -1, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, -1, 0, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, -1, -1, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, -1, 1, BreaksCastlingRightsFlag)
-	attachShiftedMove(board, kingPos, &moves, 1, -1, BreaksCastlingRightsFlag)
+// Adds the pseudolegal king moves for a position on a given board
+func attachKingMoves(board *Board, kingPos Pos, list *MoveList, onlyAttacking bool) {
+	targets := kingAttacks[PosToBitboardShifts(kingPos)] &^ board.colorOccupancy(board.ActiveColor)
+	attachBitboardMoves(board, kingPos, list, targets, NoFlag)
 
 	// castling
 	if !onlyAttacking {
-		kingside := "K"
-		queenside := "Q"
-		if board.ActiveColor == Black {
-			kingside = "k"
-			queenside = "q"
-		}
-		if strings.Contains(board.Castling, kingside) {
-			attachKingsideCastle(board, kingPos, &moves)
-		}
-		if strings.Contains(board.Castling, queenside) {
-			attachQueensideCastle(board, kingPos, &moves)
+		for _, kind := range [2]int{CastleKingsideFlag, CastleQueensideFlag} {
+			if strings.ContainsRune(board.Castling, castlingRight(board.ActiveColor, kind)) {
+				attachCastle(board, kingPos, kind, list)
+			}
 		}
 	}
-
-	return moves
 }
 
-// Attempts to add a kingside castle move if criteria is met
-func attachKingsideCastle(board Board, kingPos Pos, moves *[]Move) {
+// Attempts to add a castle of the given kind if criteria is met
+// The king and rook may start on any file (Chess960) but always end on the
+// g and f files when castling kingside and the c and d files queenside
+func attachCastle(board *Board, kingPos Pos, kind int, list *MoveList) {
 	// we already know that the rook and king have not moved
-	// check if pieces are in the way
-
-	if IsShiftIllegal(kingPos, 0, 1) {
+	rookPos := board.castlingRookPos(castlingRight(board.ActiveColor, kind))
+	kingEnd := CreatePos(kingPos.Rank, castlingEndFiles[kind][0])
+	rookEnd := CreatePos(kingPos.Rank, castlingEndFiles[kind][1])
+
+	// check if pieces other than the king and rook are in the way
+	path := calcRankSpan(kingPos.Rank, kingPos.File, kingEnd.File) |
+		calcRankSpan(kingPos.Rank, rookPos.File, rookEnd.File)
+	castlers := CalcBitboard(kingPos) | CalcBitboard(rookPos)
+	if path&board.Occupancy&^castlers != 0 {
 		return
 	}
 
-	if IsShiftIllegal(kingPos, 0, 2) {
-		return
-	}
-
-	oneShift := ShiftPos(kingPos, 0, 1)
-	twoShift := ShiftPos(kingPos, 0, 2)
-
-	if board.Get(oneShift) != None {
-		return
-	}
-
-	if board.Get(twoShift) != None {
-		return
-	}
-	copiedBoard := board.Copy()
-	copiedBoard.changeActiveColor()
-	attackingMoves := GetMoves(copiedBoard, true, false, false)
-
-	for _, move := range attackingMoves {
-		switch move.End {
-		case kingPos:
-			return
-		case oneShift:
-			return
-		case twoShift:
+	// the king may not castle out of, through or into check
+	// the rook is lifted so that it does not block an attack on where the king
+	// lands, while the rook may pass over an attacked square
+	enemies := board.colorOccupancy(oppositeColor(board.ActiveColor))
+	occupancy := board.Occupancy &^ CalcBitboard(rookPos)
+	kingPath := calcRankSpan(kingPos.Rank, kingPos.File, kingEnd.File)
+	for kingPath != 0 {
+		square := bits.TrailingZeros64(kingPath)
+		kingPath &= kingPath - 1
+		if board.attackersOf(square, occupancy)&enemies != 0 {
 			return
 		}
 	}
 
-	*moves = append(*moves, Move{Start: kingPos, End: twoShift, Flag: CastleKingsideFlag})
-
+	list.Add(createMove(board, kingPos, kingEnd, kind))
 }
 
-// Attempts to add a queenside castle move if criteria is met
-func attachQueensideCastle(board Board, kingPos Pos, moves *[]Move) {
-	// we already know that the rook and king have not moved
-	// check if pieces are in the way
-
-	if IsShiftIllegal(kingPos, 0, -1) {
-		return
-	}
-
-	if IsShiftIllegal(kingPos, 0, -2) {
-		return
-	}
-
-	if IsShiftIllegal(kingPos, 0, -3) {
-		return
-	}
-
-	oneShift := ShiftPos(kingPos, 0, -1)
-	twoShift := ShiftPos(kingPos, 0, -2)
-	threeShift := ShiftPos(kingPos, 0, -3)
-
-	if board.Get(oneShift) != None {
-		return
+// Adds a move of the given kind from pos to every position in the targets bitboard
+func attachBitboardMoves(board *Board, pos Pos, list *MoveList, targets uint64, kind int) {
+	for targets != 0 {
+		end := BitboardShiftsToPos(bits.TrailingZeros64(targets))
+		targets &= targets - 1
+		list.Add(createMove(board, pos, end, kind))
 	}
-
-	if board.Get(twoShift) != None {
-		return
-	}
-
-	if board.Get(threeShift) != None {
-		return
-	}
-
-	copiedBoard := board.Copy()
-	copiedBoard.changeActiveColor()
-	attackingMoves := GetMoves(copiedBoard, true, false, false)
-
-	for _, move := range attackingMoves {
-		switch move.End {
-		case kingPos:
-			return
-		case oneShift:
-			return
-		case twoShift:
-			return
-		case threeShift:
-			return
-
-		}
-	}
-
-	*moves = append(*moves, Move{Start: kingPos, End: twoShift, Flag: CastleQueensideFlag})
-
 }
 
-// Get pseudolegal knight moves for a position on a given board
-func getKnightMoves(board Board, pos Pos, flag int) []Move {
-	var moves []Move
-	attachShiftedMove(board, pos, &moves, 1, 2, flag)
-	attachShiftedMove(board, pos, &moves, -1, 2, flag)
-	attachShiftedMove(board, pos, &moves, 1, -2, flag)
-	attachShiftedMove(board, pos, &moves, -1, -2, flag)
-	attachShiftedMove(board, pos, &moves, 2, 1, flag)
-	attachShiftedMove(board, pos, &moves, -2, 1, flag)
-	attachShiftedMove(board, pos, &moves, 2, -1, flag)
-	attachShiftedMove(board, pos, &moves, -2, -1, flag)
-	return moves
-}
-
-// Add a give move if it meets pseudolegal criteria and attacks enemy pieces
-func attachShiftedMove(board Board, pos Pos, moves *[]Move, rankShift int, fileShift int, flag int) {
-	if !IsShiftIllegal(pos, rankShift, fileShift) {
-		end := ShiftPos(pos, rankShift, fileShift)
-		endPiece := board.Get(end)
-		if endPiece == None || (endPiece.Color() != board.ActiveColor) {
-			*moves = append(*moves, Move{Start: pos, End: end, Flag: flag})
-		}
+// Creates a move of the given kind with the capture and castling rights bits
+// set for the board it will be played on
+func createMove(board *Board, start Pos, end Pos, kind int) Move {
+	move := Move{Start: start, End: end, Flag: kind, Captured: board.Get(end)}
+	if move.IsCastle() {
+		// the king can land on its own rook in Chess960
+		move.Captured = None
 	}
-}
-
-// Get pseudolegal bishop moves for a position on a given board
-func getBishopMoves(board Board, pos Pos, flag int) []Move {
-	var moves []Move
-	moves = append(moves, getSlidingMovesInDirection(board, pos, 1, 1, flag)...)
-	moves = append(moves, getSlidingMovesInDirection(board, pos, 1, -1, flag)...)
-	moves = append(moves, getSlidingMovesInDirection(board, pos, -1, 1, flag)...)
-	moves = append(moves, getSlidingMovesInDirection(board, pos, -1, -1, flag)...)
-	return moves
-}
-
-// Get pseudolegal rook moves for a position on a given board
-func getRookMoves(board Board, pos Pos, flag int) []Move {
-	var moves []Move
-	moves = append(moves, getSlidingMovesInDirection(board, pos, 1, 0, flag)...)
-	moves = append(moves, getSlidingMovesInDirection(board, pos, 0, 1, flag)...)
-	moves = append(moves, getSlidingMovesInDirection(board, pos, -1, 0, flag)...)
-	moves = append(moves, getSlidingMovesInDirection(board, pos, 0, -1, flag)...)
-	return moves
-}
-
-// Get pseudolegal sliding moves for the piece at the given position in given direction.
-func getSlidingMovesInDirection(board Board, pos Pos, rankShift int, fileShift int, flag int) []Move {
-	piece := board.Get(pos)
-	var moves []Move
-
-	if IsShiftIllegal(pos, rankShift, fileShift) {
-		return moves
+	if kind == EnPassantFlag {
+		move.Captured = CreatePiece(Pawn | oppositeColor(board.ActiveColor))
 	}
-
-	nextPos := ShiftPos(pos, rankShift, fileShift)
-	for {
-		nextPiece := board.Get(nextPos)
-		if nextPiece != None && piece.HasSameColor(nextPiece) {
-			break
-		}
-		moves = append(moves, Move{Start: pos, End: nextPos, Flag: flag})
-
-		// pieces are different vision is blocked
-		if nextPiece != None {
-			break
-		}
-		// get next position in the sliding direction
-		if IsShiftIllegal(nextPos, rankShift, fileShift) {
-			return moves
-		}
-		nextPos = ShiftPos(nextPos, rankShift, fileShift)
-
+	if move.Captured != None {
+		move.Flag |= CaptureFlag
+	}
+	if board.Castling != "" && board.castlingRightsLost(start, end) != "" {
+		move.Flag |= BreaksCastlingRightsFlag
 	}
-	return moves
+	return move
 }
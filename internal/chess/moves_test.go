@@ -0,0 +1,23 @@
+package chess
+
+import "testing"
+
+// TestPackMove checks that every legal move in some busy positions survives
+// being packed and unpacked.
+func TestPackMove(t *testing.T) {
+	// Parameters
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	}
+
+	// Test
+	for _, fen := range fens {
+		for _, move := range GetAllLegalMoves(LoadBoardFromFEN(fen)) {
+			if unpacked := UnpackMove(move.Pack()); unpacked != move {
+				t.Errorf(`UnpackMove(%v.Pack()) = %v want match for %v`, move, unpacked, move)
+			}
+		}
+	}
+}
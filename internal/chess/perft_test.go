@@ -0,0 +1,135 @@
+package chess
+
+import (
+	_ "embed"
+	"flag"
+	"runtime"
+	"testing"
+)
+
+//go:embed testdata/perft.epd
+var perftSuite string
+
+var perftNodes = flag.Int("perft.nodes", 1000000, "skip perft suite depths expected to reach more nodes than this")
+
+// TestPerftSuite runs every position in testdata/perft.epd, checking the node
+// count at each depth small enough to run in a normal test (raise the limit
+// with -perft.nodes to run the deeper ones).
+func TestPerftSuite(t *testing.T) {
+	// Parameters
+	maxNodes := *perftNodes
+	if testing.Short() {
+		maxNodes = min(maxNodes, 100000)
+	}
+
+	// Test
+	options := PerftOptions{Workers: runtime.NumCPU()}
+	positions, err := ParsePerftSuite(perftSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range positions {
+		board := LoadBoardFromFEN(position.FEN)
+		for depth, expected := range position.Expected {
+			if expected > maxNodes {
+				continue
+			}
+			if result := RunPerft(board, depth, options).Nodes; result != expected {
+				t.Errorf(`Perft("%s", %d) = %d want match for %d`, position.FEN, depth, result, expected)
+			}
+		}
+	}
+}
+
+// TestRunPerftWorkers checks that splitting the root moves across any number
+// of workers counts the same nodes as a single worker.
+func TestRunPerftWorkers(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	depth := 3
+	expected := 97862
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	for _, workers := range []int{0, 1, 2, 7, 64} {
+		result := RunPerft(board, depth, PerftOptions{Workers: workers})
+		if result.Nodes != expected {
+			t.Errorf(`RunPerft("%s", %d, %d workers) = %d want match for %d`, fen, depth, workers, result.Nodes, expected)
+		}
+	}
+	if board.FEN() != fen {
+		t.Errorf(`RunPerft("%s") changed the board to %s`, fen, board.FEN())
+	}
+}
+
+// TestRunPerftHash checks that caching transposed subtrees, with and without
+// workers sharing the table, counts the same nodes as searching them.
+func TestRunPerftHash(t *testing.T) {
+	// Parameters
+	positions := []struct {
+		fen   string
+		depth int
+		nodes int
+	}{
+		{StartingFEN, 4, 197281},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3, 97862},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624},
+	}
+	hashMB := 4
+
+	// Test
+	for _, position := range positions {
+		board := LoadBoardFromFEN(position.fen)
+		for _, workers := range []int{1, 4} {
+			result := RunPerft(board, position.depth, PerftOptions{Workers: workers, HashMB: hashMB})
+			if result.Nodes != position.nodes {
+				t.Errorf(`RunPerft("%s", %d, %d workers, hashed) = %d want match for %d`, position.fen, position.depth, workers, result.Nodes, position.nodes)
+			}
+		}
+	}
+}
+
+// TestPerftDivide checks that the divided counts of the starting position
+// add up to the perft count and that each root move is listed once.
+func TestPerftDivide(t *testing.T) {
+	// Parameters
+	depth := 3
+	expectedMoves := 20
+	expectedNodes := 8902
+	expectedE2E4 := 600
+
+	// Test
+	board := LoadBoardFromFEN(StartingFEN)
+	divisions := PerftDivide(board, depth)
+	if len(divisions) != expectedMoves {
+		t.Fatalf(`len(PerftDivide(start, %d)) = %d want match for %d`, depth, len(divisions), expectedMoves)
+	}
+	total := 0
+	for _, division := range divisions {
+		total += division.Nodes
+		if MoveToAlgebraic(division.Move) == "e2e4" && division.Nodes != expectedE2E4 {
+			t.Errorf(`PerftDivide(start, %d) e2e4 = %d want match for %d`, depth, division.Nodes, expectedE2E4)
+		}
+	}
+	if total != expectedNodes {
+		t.Errorf(`PerftDivide(start, %d) total = %d want match for %d`, depth, total, expectedNodes)
+	}
+}
+
+// TestParsePerftSuite checks that malformed depth entries are rejected.
+func TestParsePerftSuite(t *testing.T) {
+	// Parameters
+	invalid := []string{
+		"8/8/8/8/8/8/8/8 w - - 0 1 ;D1 0",
+		StartingFEN + " ;D1",
+		StartingFEN + " ;X1 20",
+		StartingFEN + " ;D1 twenty",
+	}
+
+	// Test
+	for _, epd := range invalid {
+		if _, err := ParsePerftSuite(epd); err == nil {
+			t.Errorf(`ParsePerftSuite("%s") = nil want match for an error`, epd)
+		}
+	}
+}
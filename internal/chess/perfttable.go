@@ -0,0 +1,36 @@
+package chess
+
+import "github.com/HunterBowie/GoChessEngine/internal/hashtable"
+
+// DATA DEFINITIONS
+
+// A table caching the node counts of perft subtrees by position hash and
+// depth, shared by every perft worker
+// Each entry holds the node count shifted left by 8 bits with the depth in the
+// low 8 bits, so a count is only used for the depth it was counted to
+type perftTable struct {
+	table hashtable.Table
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Creates a table using at most the given number of megabytes
+func newPerftTable(megabytes int) *perftTable {
+	return &perftTable{table: hashtable.New(megabytes)}
+}
+
+// Returns the cached node count of the position at the given depth, false if
+// it has not been stored
+func (table *perftTable) probe(hash uint64, depth int) (int, bool) {
+	data, ok := table.table.Entry(hash).Load(hash)
+	if !ok || int(data&0xff) != depth {
+		return 0, false
+	}
+	return int(data >> 8), true
+}
+
+// Stores the node count of the position at the given depth, replacing
+// whatever was in its entry
+func (table *perftTable) store(hash uint64, depth int, nodes int) {
+	table.table.Entry(hash).Store(hash, uint64(nodes)<<8|uint64(depth))
+}
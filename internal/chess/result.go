@@ -0,0 +1,148 @@
+package chess
+
+// DATA DEFINITIONS
+
+// Who, if anyone, has won the game
+type Outcome int
+
+const (
+	Ongoing Outcome = iota
+	WhiteWins
+	BlackWins
+	Draw
+)
+
+// Why the game ended
+type Termination int
+
+const (
+	NoTermination Termination = iota
+	Checkmate
+	Stalemate
+	Repetition
+	FiftyMoveRule
+	InsufficientMaterial
+	Resignation
+	Timeout
+	Agreement
+)
+
+// The result of a game with the outcome and the reason it ended
+// An ongoing game has the NoTermination reason
+type GameResult struct {
+	Outcome     Outcome
+	Termination Termination
+}
+
+var outcomeNames = map[Outcome]string{
+	Ongoing:   "ongoing",
+	WhiteWins: "white wins",
+	BlackWins: "black wins",
+	Draw:      "draw",
+}
+
+var terminationNames = map[Termination]string{
+	NoTermination:        "none",
+	Checkmate:            "checkmate",
+	Stalemate:            "stalemate",
+	Repetition:           "threefold repetition",
+	FiftyMoveRule:        "fifty move rule",
+	InsufficientMaterial: "insufficient material",
+	Resignation:          "resignation",
+	Timeout:              "timeout",
+	Agreement:            "agreement",
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Returns the result of the game as decided by the position on the board
+// Resignation, timeout and agreement are never returned as they are not
+// decided by the board (see Resign, FlagOnTime and DrawByAgreement)
+func (board *Board) GetGameResult() GameResult {
+	noMoves := len(GetAllLegalMoves(*board)) == 0
+	inCheck := IsKingInCheck(*board)
+
+	if noMoves && inCheck {
+		return createWin(oppositeColor(board.ActiveColor), Checkmate)
+	}
+
+	if noMoves {
+		return GameResult{Outcome: Draw, Termination: Stalemate}
+	}
+
+	if board.IsInsufficientMaterial() {
+		return GameResult{Outcome: Draw, Termination: InsufficientMaterial}
+	}
+
+	if board.IsFiftyMoveRule() {
+		return GameResult{Outcome: Draw, Termination: FiftyMoveRule}
+	}
+
+	if board.IsThreefoldRepetition() {
+		return GameResult{Outcome: Draw, Termination: Repetition}
+	}
+
+	return GameResult{Outcome: Ongoing, Termination: NoTermination}
+}
+
+// Returns the result of the given color resigning
+func Resign(color int) GameResult {
+	return createWin(oppositeColor(color), Resignation)
+}
+
+// Returns the result of the given color running out of time
+// The game is drawn if the opponent cannot mate by any series of legal moves:
+// they have only their king, or neither color has the material to mate
+// Otherwise the opponent wins, even with a lone minor piece, since the pieces
+// of the color out of time can block their own king into a mate
+func FlagOnTime(board Board, color int) GameResult {
+	opponent := oppositeColor(color)
+	king := board.Bitboards[GetBitboardIndex(CreatePiece(King|opponent))]
+	if board.colorOccupancy(opponent) == king || board.IsInsufficientMaterial() {
+		return GameResult{Outcome: Draw, Termination: Timeout}
+	}
+	return createWin(opponent, Timeout)
+}
+
+// Returns the result of both players agreeing to a draw
+func DrawByAgreement() GameResult {
+	return GameResult{Outcome: Draw, Termination: Agreement}
+}
+
+// Returns true if the game has ended
+func (result GameResult) IsOver() bool {
+	return result.Outcome != Ongoing
+}
+
+// Returns the PGN result string: 1-0, 0-1, 1/2-1/2 or * if ongoing
+func (result GameResult) String() string {
+	switch result.Outcome {
+	case WhiteWins:
+		return "1-0"
+	case BlackWins:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+// Returns a readable name for the outcome such as "white wins"
+func (outcome Outcome) String() string {
+	return outcomeNames[outcome]
+}
+
+// Returns a readable name for the termination such as "checkmate"
+func (termination Termination) String() string {
+	return terminationNames[termination]
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Returns a result where the given color has won
+func createWin(color int, termination Termination) GameResult {
+	if color == White {
+		return GameResult{Outcome: WhiteWins, Termination: termination}
+	}
+	return GameResult{Outcome: BlackWins, Termination: termination}
+}
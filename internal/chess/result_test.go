@@ -0,0 +1,50 @@
+package chess
+
+import "testing"
+
+// TestCheckmateResult checks the result of a back rank mate for black.
+func TestCheckmateResult(t *testing.T) {
+	// Parameters
+	fen := "6k1/5ppp/8/8/8/8/5PPP/1r4K1 w - - 0 1"
+	expected := GameResult{Outcome: BlackWins, Termination: Checkmate}
+	expectedString := "0-1"
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	result := board.GetGameResult()
+	if result != expected {
+		t.Errorf(`GetGameResult("%s") = %s by %s want match for %s by %s`, fen, result.Outcome, result.Termination, expected.Outcome, expected.Termination)
+	}
+	if result.String() != expectedString {
+		t.Errorf(`GetGameResult("%s").String() = %s want match for %s`, fen, result.String(), expectedString)
+	}
+}
+
+// TestFlagOnTime checks that running out of time loses unless the opponent
+// has only their king or cannot mate with the material left.
+func TestFlagOnTime(t *testing.T) {
+	// Parameters
+	fen := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+
+	// Test
+	board := LoadBoardFromFEN(fen)
+	if result := FlagOnTime(board, Black); result.Outcome != WhiteWins {
+		t.Errorf(`FlagOnTime("%s", Black) = %s want match for %s`, fen, result.Outcome, WhiteWins)
+	}
+	if result := FlagOnTime(board, White); result.Outcome != Draw {
+		t.Errorf(`FlagOnTime("%s", White) = %s want match for %s`, fen, result.Outcome, Draw)
+	}
+
+	// a lone minor piece can only mate with the help of the other color
+	expected := map[string]Outcome{
+		"4k3/8/8/8/8/8/8/4KN2 b - - 0 1":   Draw,
+		"4k3/8/8/8/8/8/8/4KB2 b - - 0 1":   Draw,
+		"4k3/4p3/8/8/8/8/8/4KN2 b - - 0 1": WhiteWins,
+	}
+	for fen, want := range expected {
+		board := LoadBoardFromFEN(fen)
+		if result := FlagOnTime(board, Black); result.Outcome != want {
+			t.Errorf(`FlagOnTime("%s", Black) = %s want match for %s`, fen, result.Outcome, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package chess
+
+import (
+	"math/bits"
+	"math/rand"
+	"strings"
+)
+
+// DATA DEFINITIONS
+
+// Random keys that are combined with xor to form a position hash
+var zobristPieces [12][64]uint64 // indexed by bitboard index then square
+var zobristCastling [16]uint64   // indexed by castling mask
+var zobristEnPassant [8]uint64   // indexed by file - 1
+var zobristBlackToMove uint64
+
+// fixed so that hashes are the same on every run
+const zobristSeed = 1070372
+
+func init() {
+	random := rand.New(rand.NewSource(zobristSeed))
+	for index := range zobristPieces {
+		for square := range zobristPieces[index] {
+			zobristPieces[index][square] = random.Uint64()
+		}
+	}
+	for mask := range zobristCastling {
+		zobristCastling[mask] = random.Uint64()
+	}
+	for file := range zobristEnPassant {
+		zobristEnPassant[file] = random.Uint64()
+	}
+	zobristBlackToMove = random.Uint64()
+}
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Returns the Zobrist hash of the position
+// Equal positions (pieces, active color, castling and en passant) have equal hashes
+func (board *Board) Hash() uint64 {
+	return board.hash
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Returns the Zobrist hash of the position calculated from scratch
+func (board *Board) calcHash() uint64 {
+	hash := uint64(0)
+	for index, bitboard := range board.Bitboards {
+		for bitboard != 0 {
+			hash ^= zobristPieces[index][bits.TrailingZeros64(bitboard)]
+			bitboard &= bitboard - 1
+		}
+	}
+	if board.ActiveColor == Black {
+		hash ^= zobristBlackToMove
+	}
+	return hash ^ board.castlingHash() ^ board.enPassantHash()
+}
+
+// Returns the key for the current castling rights
+func (board *Board) castlingHash() uint64 {
+	mask := 0
+	for bit, right := range "KQkq" {
+		if strings.ContainsRune(board.Castling, right) {
+			mask |= 1 << bit
+		}
+	}
+	return zobristCastling[mask]
+}
+
+// Returns the key for the en passant file, or zero if there is no en passant
+// square or no pawn of the active color is able to capture on it
+func (board *Board) enPassantHash() uint64 {
+	if board.EnPassant == nil {
+		return 0
+	}
+	square := PosToBitboardShifts(*board.EnPassant)
+	pawns := board.Bitboards[GetBitboardIndex(CreatePiece(Pawn|board.ActiveColor))]
+	if pawnAttacks[colorIndex(oppositeColor(board.ActiveColor))][square]&pawns == 0 {
+		return 0
+	}
+	return zobristEnPassant[board.EnPassant.File-1]
+}
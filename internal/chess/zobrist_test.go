@@ -0,0 +1,65 @@
+package chess
+
+import "testing"
+
+// TestIncrementalHash walks perft trees from several positions, checking
+// that the hash updated by PlayMove and UnmakeMove always matches the hash
+// calculated from scratch.
+func TestIncrementalHash(t *testing.T) {
+	// Parameters
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	}
+	depth := 3
+
+	// Test
+	for _, fen := range fens {
+		board := LoadBoardFromFEN(fen)
+		checkIncrementalHash(t, fen, &board, depth)
+	}
+}
+
+func checkIncrementalHash(t *testing.T, fen string, board *Board, depth int) {
+	if depth == 0 {
+		return
+	}
+	for _, move := range GetAllLegalMoves(*board) {
+		before := board.Hash()
+		undo := board.PlayMove(move)
+		if hash, expected := board.Hash(), board.calcHash(); hash != expected {
+			t.Fatalf(`PlayMove(%s) from "%s" hash = %#x want match for %#x`, MoveToAlgebraic(move), fen, hash, expected)
+		}
+		checkIncrementalHash(t, fen, board, depth-1)
+		board.UnmakeMove(move, undo)
+		if hash := board.Hash(); hash != before {
+			t.Fatalf(`UnmakeMove(%s) from "%s" hash = %#x want match for %#x`, MoveToAlgebraic(move), fen, hash, before)
+		}
+	}
+}
+
+// TestTranspositionHash checks that reaching a position by different move
+// orders gives the same hash.
+func TestTranspositionHash(t *testing.T) {
+	// Parameters
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	first := []Move{
+		{Start: LoadPos("g1"), End: LoadPos("f3"), Flag: NoFlag},
+		{Start: LoadPos("g8"), End: LoadPos("f6"), Flag: NoFlag},
+		{Start: LoadPos("b1"), End: LoadPos("c3"), Flag: NoFlag},
+	}
+	second := []Move{first[2], first[1], first[0]}
+
+	// Test
+	a := LoadBoardFromFEN(fen)
+	b := LoadBoardFromFEN(fen)
+	for i := range first {
+		a.PlayMove(first[i])
+		b.PlayMove(second[i])
+	}
+	if a.Hash() != b.Hash() {
+		t.Errorf(`Hash() = %#x want match for %#x`, a.Hash(), b.Hash())
+	}
+}
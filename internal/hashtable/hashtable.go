@@ -0,0 +1,88 @@
+package hashtable
+
+import "sync/atomic"
+
+// DATA DEFINITIONS
+
+// A fixed size table of 64 bit entries indexed by position hash, which any
+// number of goroutines can read and write without locking
+// Each position maps to one entry, so positions sharing it replace each other
+type Table struct {
+	entries []Entry
+	mask    uint64
+}
+
+/*
+Entry
+
+- data: uint64
+The value stored, packed by the caller.
+
+- check: uint64
+The hash of the position stored XORed with the data. Two goroutines writing
+the entry at once can leave the data of one with the check of the other, which
+then fails to match either hash, so a torn entry reads as empty instead of as
+the wrong value.
+
+*/
+
+type Entry struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+const entryBytes = 16
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Creates a table using at most the given number of megabytes, rounded down
+// to a power of two number of entries so the hash can be masked to an index
+func New(megabytes int) Table {
+	count := uint64(1)
+	for count*2*entryBytes <= uint64(megabytes)<<20 {
+		count *= 2
+	}
+	return Table{entries: make([]Entry, count), mask: count - 1}
+}
+
+// Returns the number of entries in the table
+func (table Table) Len() int {
+	return len(table.entries)
+}
+
+// Returns the entry the position hash maps to
+func (table Table) Entry(hash uint64) *Entry {
+	return &table.entries[hash&table.mask]
+}
+
+// Empties every entry, which must not be in use
+func (table Table) Clear() {
+	for index := range table.entries {
+		table.entries[index].check.Store(0)
+		table.entries[index].data.Store(0)
+	}
+}
+
+// Returns the data stored for the position hash, false if the entry holds
+// another position, is torn or is empty
+func (entry *Entry) Load(hash uint64) (uint64, bool) {
+	data := entry.data.Load()
+	if data == 0 || entry.check.Load()^data != hash {
+		return 0, false
+	}
+	return data, true
+}
+
+// Returns the data in the entry and the hash of the position it was stored
+// for, whichever position that is
+func (entry *Entry) Peek() (uint64, uint64) {
+	data := entry.data.Load()
+	return data, entry.check.Load() ^ data
+}
+
+// Stores the data for the position hash, replacing whatever was in the entry
+// Data of 0 reads as empty
+func (entry *Entry) Store(hash uint64, data uint64) {
+	entry.data.Store(data)
+	entry.check.Store(hash ^ data)
+}
@@ -0,0 +1,59 @@
+package hashtable
+
+import "testing"
+
+// TestNewSize checks that tables use a power of two number of entries within
+// the megabytes given.
+func TestNewSize(t *testing.T) {
+	// Parameters
+	expected := map[int]int{
+		0:  1,
+		1:  1 << 16,
+		3:  1 << 17,
+		16: 1 << 20,
+	}
+
+	// Test
+	for megabytes, want := range expected {
+		if count := New(megabytes).Len(); count != want {
+			t.Errorf(`New(%d).Len() = %d want match for %d`, megabytes, count, want)
+		}
+	}
+}
+
+// TestEntryStoreLoad stores data for a position, checking that it is only read
+// back for that position and that a torn entry reads as empty.
+func TestEntryStoreLoad(t *testing.T) {
+	// Parameters
+	hash := uint64(0x9d39247e33776d41)
+	data := uint64(0x2af7398005aaa5c7)
+
+	// Test
+	table := New(1)
+	entry := table.Entry(hash)
+	if _, ok := entry.Load(hash); ok {
+		t.Errorf(`Load(%x) on an empty entry = hit want match for a miss`, hash)
+	}
+
+	entry.Store(hash, data)
+	if result, ok := entry.Load(hash); !ok || result != data {
+		t.Errorf(`Load(%x) = %x, %t want match for %x, true`, hash, result, ok, data)
+	}
+	if _, ok := entry.Load(hash ^ 1); ok {
+		t.Errorf(`Load(%x) = hit want match for a miss`, hash^1)
+	}
+	if stored, storedHash := entry.Peek(); stored != data || storedHash != hash {
+		t.Errorf(`Peek() = %x, %x want match for %x, %x`, stored, storedHash, data, hash)
+	}
+
+	// the data of one write with the check of another
+	entry.data.Store(data + 1)
+	if _, ok := entry.Load(hash); ok {
+		t.Errorf(`Load(%x) on a torn entry = hit want match for a miss`, hash)
+	}
+
+	table.Clear()
+	if _, ok := entry.Load(hash); ok {
+		t.Errorf(`Load(%x) after Clear = hit want match for a miss`, hash)
+	}
+}
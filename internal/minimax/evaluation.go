@@ -15,7 +15,7 @@ const (
 	KingValue   = 0
 )
 
-var pieceValues = map[int]int{
+var pieceValues = [7]int{
 	chess.Pawn:   PawnValue,
 	chess.Knight: KnightValue,
 	chess.Bishop: BishopValue,
@@ -26,11 +26,15 @@ var pieceValues = map[int]int{
 
 // Returns a score the reflects how good the position is for white
 func Evaluate(board chess.Board) int {
+	if board.IsInsufficientMaterial() {
+		return 0
+	}
+
 	score := 0
 
 	for _, color := range [2]int{chess.White, chess.Black} {
 		for pieceType := chess.Pawn; pieceType <= chess.King; pieceType++ {
-			score += evaluatePositions(board, chess.CreatePiece(color|pieceType))
+			score += evaluatePositions(&board, chess.CreatePiece(color|pieceType))
 		}
 	}
 
@@ -55,7 +59,7 @@ func getPieceSquareTableBonus(index int, piece chess.Piece) int {
 }
 
 // Returns total evaluation of a piece including table bonuses
-func evaluatePositions(board chess.Board, piece chess.Piece) int {
+func evaluatePositions(board *chess.Board, piece chess.Piece) int {
 	sign := 1
 	if piece.IsBlack() {
 		sign = -1
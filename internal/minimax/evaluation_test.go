@@ -22,10 +22,11 @@ func TestEvaluateStartingPos(t *testing.T) {
 }
 
 // TestHelloName calls minimax.Evaluate with the starting position with
-// only the white pawns, checking for the correct score value.
+// only the white pawns (and a black king on e8 worth nothing), checking for
+// the correct score value.
 func TestEvaluateOnlyWhitePawns(t *testing.T) {
 	// Parameters
-	fen := "8/8/8/8/8/8/PPPPPPPP/4K3 w - - 0 1"
+	fen := "4k3/8/8/8/8/8/PPPPPPPP/4K3 w - - 0 1"
 	whitePieces := PawnValue*8 + 10 + KingValue
 	blackPieces := 0
 	expectedScore := whitePieces + blackPieces
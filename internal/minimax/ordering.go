@@ -0,0 +1,182 @@
+package minimax
+
+import "github.com/HunterBowie/GoChessEngine/internal/chess"
+
+// DATA DEFINITIONS
+
+// Move scores for each stage of the order, every move in a stage scoring
+// above the stages after it
+// Quiet moves score their history, from 0 up to maxHistory, and losing
+// captures score below every quiet move
+const (
+	tableMoveScore     = 1 << 30
+	goodCaptureScore   = 1 << 28
+	killerMoveScore    = 1 << 27
+	counterMoveScore   = 1 << 26
+	maxHistory         = 1 << 24
+	losingCaptureScore = -(1 << 24)
+)
+
+// The moves which caused cutoffs so far in a search, used to order the moves
+// of positions searched later
+type moveOrderer struct {
+	killers  [maxPly][2]chess.Move
+	counters [12][64]chess.Move
+	history  [2][64][64]int
+}
+
+/*
+moveOrderer
+
+- killers: [maxPly][2]chess.Move
+The last two quiet moves that caused a cutoff at each ply. A move good in
+one position is often good in its siblings.
+
+- counters: [12][64]chess.Move
+The quiet move that last caused a cutoff in reply to a move, indexed by the
+bitboard index of the piece that moved and the square it moved to.
+
+- history: [2][64][64]int
+How often a quiet move caused a cutoff for each color, indexed by start and
+end square and weighted by the depth searched below it.
+
+*/
+
+// Picks the moves of a position lazily from best to worst score, so that no
+// time is spent ordering the moves left after a cutoff
+type movePicker struct {
+	list   chess.MoveList
+	scores [chess.MaxMoves]int
+	next   int
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Generates and scores the legal moves of the board for the search at a ply
+// The table move is searched first, then winning and equal captures by
+// MVV-LVA, killer moves, the counter move to the previous move, quiet moves
+// by history and last the captures of defended pieces worth less
+// The picker is filled in place as it is too large to copy for every node
+func (orderer *moveOrderer) fillPicker(picker *movePicker, board *chess.Board, ply int, tableMove *chess.Move, previous *chess.Move) {
+	picker.next = 0
+	picker.list.Clear()
+	chess.GenerateMoves(board, chess.AllMoves, &picker.list)
+
+	var counter chess.Move
+	if previous != nil {
+		counter = orderer.counters[pieceIndex(board, previous.End)][chess.PosToBitboardShifts(previous.End)]
+	}
+	color := colorIndex(board.ActiveColor)
+	killers := orderer.killers[ply]
+
+	for index, move := range picker.list.Slice() {
+		switch {
+		case tableMove != nil && move == *tableMove:
+			picker.scores[index] = tableMoveScore
+		case move.IsCapture() || move.IsPromotion():
+			picker.scores[index] = captureScore(board, move)
+			if isLosingCapture(board, move) {
+				picker.scores[index] += losingCaptureScore
+			} else {
+				picker.scores[index] += goodCaptureScore
+			}
+		case move == killers[0]:
+			picker.scores[index] = killerMoveScore + 1
+		case move == killers[1]:
+			picker.scores[index] = killerMoveScore
+		case move == counter:
+			picker.scores[index] = counterMoveScore
+		default:
+			start := chess.PosToBitboardShifts(move.Start)
+			end := chess.PosToBitboardShifts(move.End)
+			picker.scores[index] = orderer.history[color][start][end]
+		}
+	}
+}
+
+// Generates the legal moves of the given mode and scores them by MVV-LVA,
+// for the quiescence search
+func fillCapturePicker(picker *movePicker, board *chess.Board, mode chess.GenerationMode) {
+	picker.next = 0
+	picker.list.Clear()
+	chess.GenerateMoves(board, mode, &picker.list)
+	for index, move := range picker.list.Slice() {
+		picker.scores[index] = captureScore(board, move)
+	}
+}
+
+// Records a quiet move that caused a cutoff at a ply, depth plies above the
+// horizon, in reply to the previous move
+func (orderer *moveOrderer) recordCutoff(board *chess.Board, ply int, depth int, move chess.Move, previous *chess.Move) {
+	if move.IsCapture() || move.IsPromotion() {
+		return
+	}
+
+	killers := &orderer.killers[ply]
+	if killers[0] != move {
+		killers[1] = killers[0]
+		killers[0] = move
+	}
+
+	if previous != nil {
+		orderer.counters[pieceIndex(board, previous.End)][chess.PosToBitboardShifts(previous.End)] = move
+	}
+
+	// deeper cutoffs save more work so they count for more, and the table is
+	// halved when full so that recent cutoffs outweigh old ones
+	history := &orderer.history[colorIndex(board.ActiveColor)]
+	start := chess.PosToBitboardShifts(move.Start)
+	end := chess.PosToBitboardShifts(move.End)
+	history[start][end] += depth * depth
+	if history[start][end] >= maxHistory {
+		for start := range history {
+			for end := range history[start] {
+				history[start][end] /= 2
+			}
+		}
+	}
+}
+
+// Returns the next best move left, false once every move has been picked
+func (picker *movePicker) nextMove() (chess.Move, bool) {
+	if picker.next >= picker.list.Count {
+		return chess.Move{}, false
+	}
+
+	best := picker.next
+	for index := best + 1; index < picker.list.Count; index++ {
+		if picker.scores[index] > picker.scores[best] {
+			best = index
+		}
+	}
+
+	moves := &picker.list.Moves
+	moves[picker.next], moves[best] = moves[best], moves[picker.next]
+	picker.scores[picker.next], picker.scores[best] = picker.scores[best], picker.scores[picker.next]
+	picker.next++
+	return moves[picker.next-1], true
+}
+
+// Returns true if the capture gives up a more valuable piece than it takes on
+// a square the other color defends, which loses material when the capturing
+// piece is recaptured
+func isLosingCapture(board *chess.Board, move chess.Move) bool {
+	if !move.IsCapture() || move.IsPromotion() {
+		return false
+	}
+	attacker := board.Get(move.Start)
+	if pieceValues[attacker.Type()] <= pieceValues[move.Captured.Type()] {
+		return false
+	}
+	return board.IsSquareAttacked(move.End, move.Captured.Color())
+}
+
+// Returns the bitboard index of the piece on the position
+func pieceIndex(board *chess.Board, pos chess.Pos) int {
+	return chess.GetBitboardIndex(board.Get(pos))
+}
+
+// Returns 0 for white and 1 for black
+func colorIndex(color int) int {
+	return color / chess.Black
+}
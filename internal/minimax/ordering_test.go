@@ -0,0 +1,85 @@
+package minimax
+
+import (
+	"testing"
+
+	"github.com/HunterBowie/GoChessEngine/internal/chess"
+)
+
+// TestMovePickerOrder picks every move of a position, checking that the table
+// move comes first, then good captures including the queen taking an
+// undefended pawn, killers, the counter move and quiet moves, with the queen
+// taking a defended pawn last.
+func TestMovePickerOrder(t *testing.T) {
+	// Parameters
+	fen := "4k3/2p5/3p4/2n1p1p1/1P6/8/3Q4/4K1N1 w - - 0 1"
+	tableMove := "e1f1"
+	expected := []string{"e1f1", "b4c5", "d2g5", "g1f3", "g1h3", "d2d3"}
+	losingCapture := "d2d6"
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	var orderer moveOrderer
+	previous := chess.Move{Start: chess.LoadPos("e7"), End: chess.LoadPos("d6")}
+	table, err := chess.ParseUCIMove(board, tableMove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uci := range []string{"g1h3", "g1f3"} {
+		move, err := chess.ParseUCIMove(board, uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orderer.recordCutoff(&board, 1, 4, move, nil)
+	}
+	counter, err := chess.ParseUCIMove(board, "d2d3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderer.counters[pieceIndex(&board, previous.End)][chess.PosToBitboardShifts(previous.End)] = counter
+
+	var picker movePicker
+	orderer.fillPicker(&picker, &board, 1, &table, &previous)
+	var result []string
+	for move, ok := picker.nextMove(); ok; move, ok = picker.nextMove() {
+		result = append(result, chess.MoveToAlgebraic(move))
+	}
+	if len(result) != len(chess.GetAllLegalMoves(board)) {
+		t.Fatalf(`nextMove("%s") picked %d moves want match for %d`, fen, len(result), len(chess.GetAllLegalMoves(board)))
+	}
+	for index, want := range expected {
+		if result[index] != want {
+			t.Errorf(`nextMove("%s") #%d = %s want match for %s`, fen, index, result[index], want)
+		}
+	}
+	if result[len(result)-1] != losingCapture {
+		t.Errorf(`nextMove("%s") last = %s want match for %s`, fen, result[len(result)-1], losingCapture)
+	}
+}
+
+// TestRecordCutoffHistory records cutoffs until the history is full, checking
+// that the table is halved instead of growing past its limit.
+func TestRecordCutoffHistory(t *testing.T) {
+	// Parameters
+	fen := "4k3/8/8/8/8/8/8/4K1N1 w - - 0 1"
+	depth := 64
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	move, err := chess.ParseUCIMove(board, "g1f3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orderer moveOrderer
+	start := chess.PosToBitboardShifts(move.Start)
+	end := chess.PosToBitboardShifts(move.End)
+	for count := 0; count < 2*maxHistory/(depth*depth); count++ {
+		orderer.recordCutoff(&board, 0, depth, move, nil)
+		if history := orderer.history[0][start][end]; history >= maxHistory || history <= 0 {
+			t.Fatalf(`recordCutoff() history = %d want match for 1 to %d`, history, maxHistory-1)
+		}
+	}
+	if orderer.killers[0][0] != move {
+		t.Errorf(`recordCutoff() killer = %s want match for %s`, chess.MoveToAlgebraic(orderer.killers[0][0]), chess.MoveToAlgebraic(move))
+	}
+}
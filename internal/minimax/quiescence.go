@@ -0,0 +1,110 @@
+package minimax
+
+import "github.com/HunterBowie/GoChessEngine/internal/chess"
+
+// DATA DEFINITIONS
+
+// The most a capture is assumed to gain in position beyond the piece it takes,
+// captures that cannot raise alpha even with it are not searched
+const deltaMargin = 200
+
+// The distance from the root past which quiescence stops at the evaluation
+const maxPly = 2 * MaxDepth
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Returns the negamax score of the position for the color to move once only
+// captures and promotions are left to search, so that the search never stops
+// in the middle of an exchange
+// The color to move may stand pat on the evaluation instead of capturing,
+// except in check where every evasion is searched
+func (s *searcher) quiesce(ply int, alpha int, beta int, mayAbort bool) int {
+	if s.tick(mayAbort) {
+		return 0
+	}
+
+	if ply >= maxPly {
+		return s.evaluate()
+	}
+
+	board := s.board
+	inCheck := chess.IsKingInCheck(*board)
+
+	// captures reach the same positions in many orders, so a stored result
+	// ends the search here if its bound is tight enough
+	hash := board.Hash()
+	s.probes++
+	stored, ok := s.table.probe(hash, ply)
+	if ok {
+		s.hits++
+		switch {
+		case stored.bound == exactBound,
+			stored.bound == lowerBound && stored.score >= beta,
+			stored.bound == upperBound && stored.score <= alpha:
+			return stored.score
+		}
+	}
+
+	picker := &s.pickers[ply]
+	standPat := 0
+	if inCheck {
+		fillCapturePicker(picker, board, chess.Evasions)
+		if picker.list.Count == 0 {
+			return -MateScore + ply
+		}
+	} else {
+		standPat = s.evaluate()
+		if standPat >= beta {
+			return beta
+		}
+		alpha = max(alpha, standPat)
+		fillCapturePicker(picker, board, chess.CapturesAndPromotions)
+	}
+
+	result := tableResult{bound: upperBound}
+	for move, ok := picker.nextMove(); ok; move, ok = picker.nextMove() {
+		// delta pruning: even winning the captured piece for free and the
+		// margin cannot raise alpha
+		if !inCheck && !move.IsPromotion() &&
+			standPat+pieceValues[move.Captured.Type()]+deltaMargin <= alpha {
+			continue
+		}
+
+		undo := board.PlayMove(move)
+		score := -s.quiesce(ply+1, -beta, -alpha, mayAbort)
+		board.UnmakeMove(move, undo)
+		if s.aborted {
+			return 0
+		}
+
+		if score > alpha {
+			alpha = score
+			result.move = &move
+			result.bound = exactBound
+			if alpha >= beta {
+				result.bound = lowerBound
+				break
+			}
+		}
+	}
+
+	result.score = alpha
+	s.table.store(hash, ply, s.age, result)
+	return alpha
+}
+
+// Returns the MVV-LVA score of a move, ordering the most valuable victims
+// first and then the least valuable attackers, with promotions gaining the
+// piece the pawn becomes
+// Quiet moves score below every capture and promotion
+func captureScore(board *chess.Board, move chess.Move) int {
+	score := 0
+	if move.IsCapture() {
+		attacker := board.Get(move.Start)
+		score += pieceValues[move.Captured.Type()]*10 - pieceValues[attacker.Type()]/10 + 1
+	}
+	if move.IsPromotion() {
+		score += pieceValues[move.PromotionType()] * 10
+	}
+	return score
+}
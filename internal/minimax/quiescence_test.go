@@ -0,0 +1,55 @@
+package minimax
+
+import (
+	"testing"
+
+	"github.com/HunterBowie/GoChessEngine/internal/chess"
+)
+
+// TestQuiesceRecapture checks that taking a defended pawn with the queen is
+// seen to lose the queen, so the score stands pat on the evaluation.
+func TestQuiesceRecapture(t *testing.T) {
+	// Parameters
+	fen := "4k3/8/3p4/4p3/8/8/8/Q3K3 w - - 0 1"
+	blunder := "a1e5"
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	s := searcher{board: &board, table: NewTranspositionTable(1)}
+	if score, want := s.quiesce(0, -infinity, infinity, false), s.evaluate(); score != want {
+		t.Errorf(`quiesce("%s") = %d want match for %d`, fen, score, want)
+	}
+	results := Search(board, 200)
+	if results.BestMove == nil || chess.MoveToAlgebraic(*results.BestMove) == blunder {
+		t.Errorf(`Search("%s") = %v want match for a move other than %s`, fen, results.BestMove, blunder)
+	}
+}
+
+// TestQuiesceWinsMaterial checks that a capture winning a piece is found
+// past the horizon.
+func TestQuiesceWinsMaterial(t *testing.T) {
+	// Parameters
+	fen := "4k3/8/8/4n3/8/8/8/Q3K3 w - - 0 1"
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	s := searcher{board: &board, table: NewTranspositionTable(1)}
+	if score, least := s.quiesce(0, -infinity, infinity, false), s.evaluate()+KnightValue/2; score < least {
+		t.Errorf(`quiesce("%s") = %d want match for at least %d`, fen, score, least)
+	}
+}
+
+// TestQuiesceMated checks that a position in check with no evasions scores
+// as mated instead of standing pat.
+func TestQuiesceMated(t *testing.T) {
+	// Parameters
+	fen := "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1"
+	ply := 3
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	s := searcher{board: &board, table: NewTranspositionTable(1)}
+	if score := s.quiesce(ply, -infinity, infinity, false); score != -MateScore+ply {
+		t.Errorf(`quiesce("%s") = %d want match for %d`, fen, score, -MateScore+ply)
+	}
+}
@@ -1,84 +1,300 @@
 package minimax
 
 import (
-	"math"
 	"time"
 
 	"github.com/HunterBowie/GoChessEngine/internal/chess"
 )
 
+// The deepest iteration Search will start
+const MaxDepth = 64
+
+// Scores for forced mates, MateScore less the plies until the mate for the
+// side that mates
+// Any score beyond MateThreshold is a mate, which no evaluation can reach
+const (
+	MateScore     = 1000000
+	MateThreshold = MateScore - 2*MaxDepth
+	infinity      = MateScore + 1
+)
+
+// How many nodes are searched between checks of the clock
+const nodesPerClockCheck = 1024
+
 type SearchResults struct {
-	BestMove *chess.Move
-	Score    int
+	BestMove    *chess.Move
+	Score       int
+	Mate        int
+	Depth       int
+	Nodes       int
+	TableProbes int
+	TableHits   int
+}
+
+/*
+SearchResults
+
+- Score: int
+The score of the best move for white in centipawns, like Evaluate. A forced
+mate scores MateScore less the plies until mate, negated if black mates.
+
+- Mate: int
+The number of moves until a forced mate, positive if white mates and
+negative if black mates. 0 if no mate was found, when Score is centipawns.
+
+- Depth: int
+The depth of the deepest iteration the best move comes from. An iteration cut
+short by the time budget counts if its best move was used.
+
+- Nodes: int
+The number of positions searched across every iteration.
+
+- TableProbes: int
+The number of positions looked up in the transposition table.
+
+- TableHits: int
+The number of lookups that found the position stored.
+
+*/
+
+// The state shared by every node of one search
+type searcher struct {
+	board    *chess.Board
+	deadline time.Time
+	table    *TranspositionTable
+	age      int
+	orderer  moveOrderer
+	played   [maxPly]chess.Move
+	pickers  [maxPly]movePicker
+	nodes    int
+	probes   int
+	hits     int
+	aborted  bool
 }
 
+// Searches to depth 1, 2, 3... until the time budget is spent, returning the
+// best move of the last iteration searched
+// The first iteration always completes so that a move is found, and an
+// iteration cut short is only used if its best move scores higher than the
+// last completed one
 func Search(board chess.Board, timeMilliseconds int) SearchResults {
-	startTime := time.Now()
-	return search(board, 3, math.MinInt, math.MaxInt, startTime, int64(timeMilliseconds))
+	return SearchWithTable(board, timeMilliseconds, defaultTable)
 }
 
-// Search performs a minimax search to the given depth
-// Returns the bestmove and associated score
-func search(board chess.Board, depth int, alpha int, beta int, timeStarted time.Time, totalMilliseconds int64) SearchResults {
-	if depth == 0 {
-		return SearchResults{nil, Evaluate(board)}
+// Searches like Search, caching results in the given transposition table
+// The table keeps results between searches, so it can be reused for the
+// next position of the same game
+func SearchWithTable(board chess.Board, timeMilliseconds int, table *TranspositionTable) SearchResults {
+	s := searcher{
+		board:    &board,
+		deadline: time.Now().Add(time.Duration(timeMilliseconds) * time.Millisecond),
+		table:    table,
+		age:      table.nextAge(),
 	}
 
+	var best SearchResults
 	moves := chess.GetAllLegalMoves(board)
+	for depth := 1; depth <= MaxDepth; depth++ {
+		// the previous best move is searched first so that a cut short
+		// iteration has always looked at it
+		if best.BestMove != nil {
+			moves = moveToFront(moves, *best.BestMove)
+		}
 
-	if len(moves) == 0 {
-		if chess.IsKingInCheck(board) {
-			if board.ActiveColor == chess.White {
-				return SearchResults{nil, math.MinInt}
+		result := s.searchRoot(moves, depth, depth > 1)
+		if s.aborted {
+			if result.BestMove != nil && result.Score > best.Score {
+				best = result
 			}
-			return SearchResults{nil, math.MaxInt}
-		} else {
-			return SearchResults{nil, 0}
+			break
+		}
+		best = result
+
+		// nothing changes with more depth once the only move or a mate is
+		// found, as every shorter mate has already been searched
+		if len(moves) <= 1 || abs(best.Score) >= MateThreshold {
+			break
 		}
 	}
 
-	if depth == 1 {
-		moves = chess.GetMoves(board, false, true, true)
+	// scores are searched for the color to move but reported for white
+	best.Mate = mateInMoves(best.Score)
+	if board.ActiveColor == chess.Black {
+		best.Score = -best.Score
+		best.Mate = -best.Mate
 	}
+	best.Nodes = s.nodes
+	best.TableProbes = s.probes
+	best.TableHits = s.hits
+	return best
+}
 
-	var bestResult *SearchResults
+// Returns the fraction of transposition table lookups that found the position
+func (results SearchResults) TableHitRate() float64 {
+	if results.TableProbes == 0 {
+		return 0
+	}
+	return float64(results.TableHits) / float64(results.TableProbes)
+}
 
-	maximizing := true
+// PRIVATE FUNCTION DEFINITIONS
 
-	if board.ActiveColor == chess.Black {
-		maximizing = false
-	}
+// Searches each root move to the given depth, returning the best among those
+// searched before the time budget ran out if it may abort
+func (s *searcher) searchRoot(moves []chess.Move, depth int, mayAbort bool) SearchResults {
+	best := SearchResults{Score: -infinity, Depth: depth}
+	alpha := -infinity
 
 	for _, move := range moves {
-		boardCopy := board.Copy()
-		boardCopy.PlayMove(move)
-		result := search(boardCopy, depth-1, alpha, beta, timeStarted, totalMilliseconds)
-		result.BestMove = &move
-
-		if bestResult == nil {
-			bestResult = &result
-			continue
+		s.played[0] = move
+		undo := s.board.PlayMove(move)
+		score := -s.search(depth-1, 1, -infinity, -alpha, mayAbort)
+		s.board.UnmakeMove(move, undo)
+		if s.aborted {
+			break
 		}
 
-		// if time.Since(timeStarted).Milliseconds() > totalMilliseconds {
-		// 	break
-		// }
+		if score > best.Score {
+			best.BestMove = &move
+			best.Score = score
+		}
+		alpha = max(alpha, score)
+	}
 
-		if maximizing && result.Score > bestResult.Score {
-			bestResult = &result
-			alpha = max(alpha, result.Score)
-			if beta <= alpha {
-				break
+	return best
+}
+
+// Returns the negamax score of the position for the color to move, searched
+// to the given depth with alpha-beta pruning
+// ply is the distance from the root, so that nearer mates score higher
+// Moves are played and unmade in place so the board is unchanged on return,
+// including when the search is aborted because the time budget ran out
+func (s *searcher) search(depth int, ply int, alpha int, beta int, mayAbort bool) int {
+	if s.tick(mayAbort) {
+		return 0
+	}
+
+	// mate distance pruning: no line from here can do better than mating on
+	// the next move or worse than being mated now
+	alpha = max(alpha, -MateScore+ply)
+	beta = min(beta, MateScore-ply-1)
+	if alpha >= beta {
+		return alpha
+	}
+
+	// captures are searched past the horizon until the position is quiet
+	if depth == 0 {
+		return s.quiesce(ply, alpha, beta, mayAbort)
+	}
+
+	board := s.board
+
+	// a stored result searched at least as deep ends the search here if its
+	// bound is tight enough, otherwise its best move is searched first
+	hash := board.Hash()
+	s.probes++
+	stored, ok := s.table.probe(hash, ply)
+	if ok {
+		s.hits++
+		if stored.depth >= depth {
+			switch {
+			case stored.bound == exactBound,
+				stored.bound == lowerBound && stored.score >= beta,
+				stored.bound == upperBound && stored.score <= alpha:
+				return stored.score
 			}
-		} else if !maximizing && result.Score < bestResult.Score {
-			bestResult = &result
-			beta = min(beta, result.Score)
-			if beta <= alpha {
+		}
+	}
+
+	var tableMove *chess.Move
+	if ok {
+		tableMove = stored.move
+	}
+	previous := &s.played[ply-1]
+	picker := &s.pickers[ply]
+	s.orderer.fillPicker(picker, board, ply, tableMove, previous)
+
+	if picker.list.Count == 0 {
+		if chess.IsKingInCheck(*board) {
+			return -MateScore + ply
+		}
+		return 0
+	}
+
+	result := tableResult{depth: depth, bound: upperBound}
+	for move, ok := picker.nextMove(); ok; move, ok = picker.nextMove() {
+		s.played[ply] = move
+		undo := board.PlayMove(move)
+		score := -s.search(depth-1, ply+1, -beta, -alpha, mayAbort)
+		board.UnmakeMove(move, undo)
+		if s.aborted {
+			return 0
+		}
+
+		if score > alpha {
+			alpha = score
+			result.move = &move
+			result.bound = exactBound
+			if alpha >= beta {
+				result.bound = lowerBound
+				s.orderer.recordCutoff(board, ply, depth, move, previous)
 				break
 			}
 		}
 	}
 
-	return *bestResult
+	result.score = alpha
+	s.table.store(hash, ply, s.age, result)
+	return alpha
+}
 
+// Counts a node, returning true if the search has been aborted because the
+// time budget ran out
+// The clock is only checked every nodesPerClockCheck nodes
+func (s *searcher) tick(mayAbort bool) bool {
+	s.nodes++
+	if mayAbort && s.nodes%nodesPerClockCheck == 0 && time.Now().After(s.deadline) {
+		s.aborted = true
+	}
+	return s.aborted
+}
+
+// Returns the evaluation of the position for the color to move
+func (s *searcher) evaluate() int {
+	if s.board.ActiveColor == chess.Black {
+		return -Evaluate(*s.board)
+	}
+	return Evaluate(*s.board)
+}
+
+// Returns the number of moves until mate for a score, positive if the color
+// to move mates, or 0 if the score is not a mate
+func mateInMoves(score int) int {
+	if score >= MateThreshold {
+		return (MateScore - score + 1) / 2
+	}
+	if score <= -MateThreshold {
+		return -(MateScore + score) / 2
+	}
+	return 0
+}
+
+// Returns the absolute value of the integer
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Returns the moves with the given move moved to the front
+func moveToFront(moves []chess.Move, move chess.Move) []chess.Move {
+	for index := range moves {
+		if moves[index] == move {
+			copy(moves[1:index+1], moves[:index])
+			moves[0] = move
+			break
+		}
+	}
+	return moves
 }
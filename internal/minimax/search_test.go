@@ -22,3 +22,54 @@ func TestTimeDepth3(t *testing.T) {
 	fmt.Println(searchResults.BestMove)
 
 }
+
+// TestSearchTimeBudget searches a busy middlegame position, checking that the
+// search stops close to its budget with a legal move.
+func TestSearchTimeBudget(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	budget := 300
+	slack := 200
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	start := time.Now()
+	results := Search(board, budget)
+	elapsed := time.Since(start).Milliseconds()
+
+	if elapsed > int64(budget+slack) {
+		t.Errorf(`Search("%s", %d) took %dms want match for at most %dms`, fen, budget, elapsed, budget+slack)
+	}
+	if results.BestMove == nil || results.Depth < 2 {
+		t.Fatalf(`Search("%s", %d) = %v at depth %d want match for a move at depth 2 or more`, fen, budget, results.BestMove, results.Depth)
+	}
+	if _, err := chess.ParseUCIMove(board, chess.MoveToAlgebraic(*results.BestMove)); err != nil {
+		t.Errorf(`Search("%s", %d) = %s want match for a legal move`, fen, budget, chess.MoveToAlgebraic(*results.BestMove))
+	}
+}
+
+// TestSearchMate checks that forced mates are found and reported as mate in N
+// for white, positive when white mates and negative when black mates.
+func TestSearchMate(t *testing.T) {
+	// Parameters
+	expected := map[string]int{
+		"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1":                                   1,  // back rank mate
+		"r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1":                                   -1, // back rank mate for black
+		"6k1/5ppp/8/8/8/8/8/6K1 w - - 0 1":                                    0,  // no mate
+		"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4": 1,  // scholar's mate
+		"k7/8/2K5/8/8/8/8/7R w - - 0 1":                                       2,  // king cuts off the escape then rook mate
+	}
+	budget := 500
+
+	// Test
+	for fen, want := range expected {
+		board := chess.LoadBoardFromFEN(fen)
+		results := Search(board, budget)
+		if results.Mate != want {
+			t.Errorf(`Search("%s").Mate = %d want match for %d`, fen, results.Mate, want)
+		}
+		if want != 0 && abs(results.Score) < MateThreshold {
+			t.Errorf(`Search("%s").Score = %d want match for a mate score`, fen, results.Score)
+		}
+	}
+}
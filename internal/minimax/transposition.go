@@ -0,0 +1,122 @@
+package minimax
+
+import (
+	"sync/atomic"
+
+	"github.com/HunterBowie/GoChessEngine/internal/chess"
+	"github.com/HunterBowie/GoChessEngine/internal/hashtable"
+)
+
+// DATA DEFINITIONS
+
+// The size of the table Search uses
+const DefaultTableMegabytes = 16
+
+// How a stored score bounds the true score of the position
+const (
+	exactBound = iota
+	lowerBound
+	upperBound
+)
+
+// A table caching search results by position hash, shared by searches on any
+// number of goroutines
+// Each entry holds the packed best move in the low 22 bits (0 if there is
+// none), then the score offset by scoreOffset (24 bits), the depth searched
+// (8 bits), the bound (2 bits) and the age of the search that stored it
+// (8 bits), which decides whether another position may replace it
+type TranspositionTable struct {
+	table hashtable.Table
+	age   atomic.Uint32
+}
+
+// A search result read back from the table
+type tableResult struct {
+	move  *chess.Move
+	score int
+	depth int
+	bound int
+}
+
+// Added to scores so that they are stored as unsigned integers
+const scoreOffset = 1 << 23
+
+// The table Search uses when not given one
+var defaultTable = NewTranspositionTable(DefaultTableMegabytes)
+
+// PUBLIC FUNCTION DEFINITIONS
+
+// Creates a table using at most the given number of megabytes
+func NewTranspositionTable(megabytes int) *TranspositionTable {
+	return &TranspositionTable{table: hashtable.New(megabytes)}
+}
+
+// Empties the table, which must not be in use by a search
+func (table *TranspositionTable) Clear() {
+	table.table.Clear()
+}
+
+// PRIVATE FUNCTION DEFINITIONS
+
+// Starts a new search, so that entries from older searches are replaced first
+func (table *TranspositionTable) nextAge() int {
+	return int(table.age.Add(1) & 0xff)
+}
+
+// Returns the stored result for the position, false if it has not been stored
+// ply is the distance from the root, which mate scores are stored relative to
+func (table *TranspositionTable) probe(hash uint64, ply int) (tableResult, bool) {
+	data, ok := table.table.Entry(hash).Load(hash)
+	if !ok {
+		return tableResult{}, false
+	}
+
+	result := tableResult{
+		score: int(data>>22&0xffffff) - scoreOffset,
+		depth: int(data >> 46 & 0xff),
+		bound: int(data >> 54 & 0b11),
+	}
+	if packed := uint32(data & 0x3fffff); packed != 0 {
+		move := chess.UnpackMove(packed)
+		result.move = &move
+	}
+
+	// a mate stored as plies from the node is made plies from this root
+	if result.score >= MateThreshold {
+		result.score -= ply
+	} else if result.score <= -MateThreshold {
+		result.score += ply
+	}
+	return result, true
+}
+
+// Stores the result for the position unless its entry holds a deeper result
+// for another position from the same search
+// Exact results and results from older searches are always replaced
+func (table *TranspositionTable) store(hash uint64, ply int, age int, result tableResult) {
+	entry := table.table.Entry(hash)
+	old, oldHash := entry.Peek()
+	sameAge := int(old>>56) == age
+	if old != 0 && sameAge && oldHash != hash &&
+		result.bound != exactBound && int(old>>46&0xff) > result.depth {
+		return
+	}
+
+	// mates are stored as plies from this node, which is the same for every
+	// path that reaches it
+	score := result.score
+	if score >= MateThreshold {
+		score += ply
+	} else if score <= -MateThreshold {
+		score -= ply
+	}
+
+	data := uint64(score+scoreOffset)<<22 |
+		uint64(result.depth)<<46 |
+		uint64(result.bound)<<54 |
+		uint64(age)<<56
+	if result.move != nil {
+		data |= uint64(result.move.Pack())
+	}
+	entry.Store(hash, data)
+}
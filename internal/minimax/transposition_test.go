@@ -0,0 +1,78 @@
+package minimax
+
+import (
+	"testing"
+
+	"github.com/HunterBowie/GoChessEngine/internal/chess"
+)
+
+// TestTranspositionTableStore stores results and reads them back, checking
+// that mate scores are adjusted to the ply they are probed from.
+func TestTranspositionTableStore(t *testing.T) {
+	// Parameters
+	hash := uint64(0x9d39247e33776d41)
+	move := chess.Move{Start: chess.LoadPos("e2"), End: chess.LoadPos("e4"), Flag: chess.PawnDoublePushFlag}
+	expected := map[int]int{
+		-120:              -120,
+		MateScore - 7:     MateScore - 5, // mate stored at ply 4, probed at ply 2
+		-(MateScore - 9):  -(MateScore - 7),
+		MateThreshold - 1: MateThreshold - 1,
+	}
+
+	// Test
+	table := NewTranspositionTable(1)
+	for score, want := range expected {
+		table.store(hash, 4, 1, tableResult{move: &move, score: score, depth: 5, bound: lowerBound})
+		result, ok := table.probe(hash, 2)
+		if !ok {
+			t.Fatalf(`probe(%x) = miss want match for a hit`, hash)
+		}
+		if result.score != want || result.depth != 5 || result.bound != lowerBound || result.move == nil || *result.move != move {
+			t.Errorf(`probe(%x) = %d at depth %d want match for %d at depth 5`, hash, result.score, result.depth, want)
+		}
+	}
+	if _, ok := table.probe(hash^1, 2); ok {
+		t.Errorf(`probe(%x) = hit want match for a miss`, hash^1)
+	}
+}
+
+// TestTranspositionTableReplace checks that a deeper result from the same
+// search keeps its entry, while results from older searches are replaced.
+func TestTranspositionTableReplace(t *testing.T) {
+	// Parameters
+	table := NewTranspositionTable(1)
+	hash := uint64(0x1234)
+	other := hash + uint64(table.table.Len()) // same entry, different position
+
+	// Test
+	table.store(hash, 0, 1, tableResult{score: 10, depth: 6, bound: upperBound})
+	table.store(other, 0, 1, tableResult{score: 20, depth: 2, bound: upperBound})
+	if _, ok := table.probe(hash, 0); !ok {
+		t.Errorf(`store(%x) replaced a deeper entry from the same search`, other)
+	}
+	table.store(other, 0, 2, tableResult{score: 20, depth: 2, bound: upperBound})
+	if _, ok := table.probe(other, 0); !ok {
+		t.Errorf(`store(%x) did not replace an entry from an older search`, other)
+	}
+}
+
+// TestSearchTableHits searches a middlegame position, checking that the
+// transposition table is used and the move found is legal.
+func TestSearchTableHits(t *testing.T) {
+	// Parameters
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	budget := 300
+
+	// Test
+	board := chess.LoadBoardFromFEN(fen)
+	results := SearchWithTable(board, budget, NewTranspositionTable(4))
+	if results.TableProbes == 0 || results.TableHits == 0 || results.TableHitRate() > 1 {
+		t.Errorf(`SearchWithTable("%s").TableHits = %d of %d want match for some hits`, fen, results.TableHits, results.TableProbes)
+	}
+	if results.BestMove == nil {
+		t.Fatalf(`SearchWithTable("%s").BestMove = nil want match for a move`, fen)
+	}
+	if _, err := chess.ParseUCIMove(board, chess.MoveToAlgebraic(*results.BestMove)); err != nil {
+		t.Errorf(`SearchWithTable("%s") = %s want match for a legal move`, fen, chess.MoveToAlgebraic(*results.BestMove))
+	}
+}
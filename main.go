@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/HunterBowie/GoChessEngine/internal/chess"
@@ -10,10 +11,33 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// The time the bot searches for when a request does not give one
+const defaultSearchMilliseconds = 1000
+
+// The longest time a request may ask the bot to search for
+const maxSearchMilliseconds = 60000
+
+// The move_flag clients are sent for a plain move that breaks castling rights,
+// from before the capture and castling rights flags could be combined
+const clientBreaksCastlingRightsFlag = 9
+
 type BestMoveResponse struct {
 	FEN      string `json:"fen"`
 	BestMove string `json:"best_move"`
 	MoveFlag int    `json:"move_flag"`
+	Result   string `json:"result"`
+}
+
+type ErrorResponse struct {
+	FEN   string `json:"fen"`
+	Error string `json:"error"`
+}
+
+type GameResultResponse struct {
+	FEN         string `json:"fen"`
+	Result      string `json:"result"`
+	Outcome     string `json:"outcome"`
+	Termination string `json:"termination"`
 }
 
 type EvalResponse struct {
@@ -22,28 +46,47 @@ type EvalResponse struct {
 }
 
 // GetBotMove handles the bot best move generation requests
+// The optional movetime query gives the search time in milliseconds, at most
+// maxSearchMilliseconds
 func GetBotMove(c *gin.Context) {
 	fen := c.Query("fen")
 
-	board := chess.LoadBoardFromFEN(fen)
+	board, err := chess.ParseFEN(fen)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, ErrorResponse{FEN: fen, Error: err.Error()})
+		return
+	}
+
+	moveTime, err := strconv.Atoi(c.DefaultQuery("movetime", strconv.Itoa(defaultSearchMilliseconds)))
+	if err != nil || moveTime < 1 || moveTime > maxSearchMilliseconds {
+		c.IndentedJSON(http.StatusBadRequest, ErrorResponse{FEN: fen, Error: fmt.Sprintf("movetime must be a number of milliseconds from 1 to %d", maxSearchMilliseconds)})
+		return
+	}
 
 	var bestMove string
 	var flag int
 
-	if board.FullMoves == 1 && board.ActiveColor == chess.White {
-		move := minimax.GetOpeningWhiteMove()
-		bestMove = chess.MoveToAlgebraic(move)
-		flag = move.Flag
-	} else {
-		results := minimax.Search(board, 1)
-		bestMove = chess.MoveToAlgebraic(*results.BestMove)
-		flag = results.BestMove.Flag
+	// a finished game has no best move, the result tells the client why
+	result := board.GetGameResult()
+
+	if !result.IsOver() {
+		// the opening moves are for the standard starting position only
+		if board.FullMoves == 1 && board.ActiveColor == chess.White && !board.Chess960 {
+			move := minimax.GetOpeningWhiteMove()
+			bestMove = chess.MoveToAlgebraic(move)
+			flag = clientMoveFlag(move)
+		} else {
+			results := minimax.Search(board, moveTime)
+			bestMove = chess.MoveToUCI(board, *results.BestMove)
+			flag = clientMoveFlag(*results.BestMove)
+		}
 	}
 
 	output := BestMoveResponse{
 		FEN:      fen,
 		BestMove: bestMove,
 		MoveFlag: flag,
+		Result:   result.String(),
 	}
 
 	c.IndentedJSON(http.StatusOK, output)
@@ -53,7 +96,11 @@ func GetBotMove(c *gin.Context) {
 func GetBotEval(c *gin.Context) {
 	fen := c.Query("fen")
 
-	board := chess.LoadBoardFromFEN(fen)
+	board, err := chess.ParseFEN(fen)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, ErrorResponse{FEN: fen, Error: err.Error()})
+		return
+	}
 
 	score := minimax.Evaluate(board)
 
@@ -65,6 +112,27 @@ func GetBotEval(c *gin.Context) {
 
 }
 
+// GetGameResult handles the game result requests
+func GetGameResult(c *gin.Context) {
+	fen := c.Query("fen")
+
+	board, err := chess.ParseFEN(fen)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, ErrorResponse{FEN: fen, Error: err.Error()})
+		return
+	}
+
+	result := board.GetGameResult()
+
+	output := GameResultResponse{
+		FEN:         fen,
+		Result:      result.String(),
+		Outcome:     result.Outcome.String(),
+		Termination: result.Termination.String(),
+	}
+	c.IndentedJSON(http.StatusOK, output)
+}
+
 func main() {
 	router := gin.Default()
 	// CORS middleware
@@ -82,10 +150,21 @@ func main() {
 	})
 	router.GET("/minimax/getBestMove", GetBotMove)
 	router.GET("/minimax/getEval", GetBotEval)
+	router.GET("/minimax/getGameResult", GetGameResult)
 
 	router.Run(":8080")
 }
 
+// clientMoveFlag returns the move_flag clients expect for a move: the kind of
+// move, or clientBreaksCastlingRightsFlag for a plain move that breaks
+// castling rights, never the combinable capture and castling rights bits
+func clientMoveFlag(move chess.Move) int {
+	if move.Kind() == chess.NoFlag && move.BreaksCastlingRights() {
+		return clientBreaksCastlingRightsFlag
+	}
+	return move.Kind()
+}
+
 // printBoard prints a chess board to the console
 func printBoard(board chess.Board) {
 	for rank := 8; rank >= 1; rank-- {